@@ -0,0 +1,665 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes as a string like "6s" in JSON
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string such as "500ms" or "1m30s"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"6s\": %v", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Config is the complete bot configuration
+type Config struct {
+	DataDir     string            `json:"data_dir"`
+	Telegram    TelegramConfig    `json:"telegram"`
+	Workers     WorkersConfig     `json:"workers"`
+	RateLimit   RateLimitConfig   `json:"rate_limit"`
+	Handlers    HandlersConfig    `json:"handlers"`
+	APIs        APIsConfig        `json:"apis"`
+	Resilience  ResilienceConfig  `json:"resilience"`
+	Corpus      CorpusConfig      `json:"corpus"`
+	Images      ImagesConfig      `json:"images"`
+	History     HistoryConfig     `json:"history"`
+	Deliveries  DeliveriesConfig  `json:"deliveries"`
+	Prefetch    PrefetchConfig    `json:"prefetch"`
+	Translation TranslationConfig `json:"translation"`
+	Moods       []MoodConfig      `json:"moods"`
+	Languages   []LanguageConfig  `json:"languages"`
+}
+
+// TelegramConfig configures how the bot talks to Telegram
+type TelegramConfig struct {
+	Token           string   `json:"token"`
+	APIEndpoint     string   `json:"api_endpoint"` // Bot API URL format with %s for token and method
+	Mode            string   `json:"mode"`         // "polling" or "webhook"
+	WebhookAddr     string   `json:"webhook_addr"`
+	WebhookPath     string   `json:"webhook_path"`
+	WebhookURL      string   `json:"webhook_url"`
+	WebhookSecret   string   `json:"webhook_secret"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+}
+
+// WorkersConfig configures the update worker pool
+type WorkersConfig struct {
+	Count     int `json:"count"`
+	QueueSize int `json:"queue_size"`
+}
+
+// RateLimitConfig configures the per-user rate limit
+type RateLimitConfig struct {
+	Limit  int      `json:"limit"`
+	Window Duration `json:"window"`
+}
+
+// HandlersConfig configures deadlines and behaviour for handling a single update
+type HandlersConfig struct {
+	ContentTimeout Duration `json:"content_timeout"` // fetching, translating and sending content
+	UITimeout      Duration `json:"ui_timeout"`      // translating menus and help text
+	Repicks        int      `json:"repicks"`         // extra fetches when the user has seen the content recently
+	HistorySize    int      `json:"history_size"`    // items listed by /history
+	AdminIDs       []int64  `json:"admin_ids"`       // Telegram user IDs allowed to use /status
+}
+
+// APIConfig configures a single upstream HTTP API
+type APIConfig struct {
+	BaseURL string   `json:"base_url"`
+	Timeout Duration `json:"timeout"`
+}
+
+// UnsplashConfig configures the Unsplash API
+type UnsplashConfig struct {
+	APIConfig
+	AccessKey string `json:"access_key"`
+	AppName   string `json:"app_name"` // utm_source in attribution links, as Unsplash's guidelines require
+}
+
+// APIsConfig configures the upstream content APIs
+type APIsConfig struct {
+	ZenQuotes    APIConfig      `json:"zenquotes"`
+	JokeAPI      APIConfig      `json:"jokeapi"`
+	UselessFacts APIConfig      `json:"uselessfacts"`
+	DummyJSON    APIConfig      `json:"dummyjson"`
+	Unsplash     UnsplashConfig `json:"unsplash"`
+}
+
+// ResilienceConfig configures retries and circuit breaking for the content APIs
+type ResilienceConfig struct {
+	Retry   RetryConfig   `json:"retry"`
+	Breaker BreakerConfig `json:"breaker"`
+}
+
+// RetryConfig configures retries with jittered exponential backoff
+type RetryConfig struct {
+	MaxAttempts int      `json:"max_attempts"` // total attempts per provider, including the first
+	BaseDelay   Duration `json:"base_delay"`
+	MaxDelay    Duration `json:"max_delay"`
+}
+
+// BreakerConfig configures the per-provider circuit breaker
+type BreakerConfig struct {
+	FailureThreshold int      `json:"failure_threshold"` // consecutive failed fetches before the circuit opens
+	Cooldown         Duration `json:"cooldown"`          // how long an open circuit rejects calls
+}
+
+// TranslationConfig configures the translation backends
+type TranslationConfig struct {
+	Backend        string               `json:"backend"`   // mymemory, libretranslate or none
+	Fallbacks      []string             `json:"fallbacks"` // backends tried in order when the primary fails or is over quota
+	MyMemory       TranslationAPIConfig `json:"mymemory"`
+	LibreTranslate TranslationAPIConfig `json:"libretranslate"` // any LibreTranslate-compatible server, e.g. a self-hosted one
+	CacheSize      int                  `json:"cache_size"`     // translations kept in memory and in <data_dir>/translations.json
+}
+
+// TranslationAPIConfig configures one translation API
+type TranslationAPIConfig struct {
+	APIConfig
+	APIKey     string `json:"api_key"`
+	DailyQuota int    `json:"daily_quota"` // characters per UTC day, 0 for no limit
+}
+
+// TranslationBackends lists the backend names translation can be configured with
+var TranslationBackends = []string{"mymemory", "libretranslate", "none"}
+
+// CorpusConfig configures the embedded offline corpus. It always ends every
+// mood's fallback chain; Primary moves it to the front instead.
+type CorpusConfig struct {
+	Primary bool `json:"primary"`
+}
+
+// ImagesConfig configures the local image folders. Each mood reads from a
+// subfolder named after it, e.g. images/funny.
+type ImagesConfig struct {
+	LocalDir string `json:"local_dir"`
+}
+
+// HistoryConfig configures the per-user history of delivered content
+type HistoryConfig struct {
+	Window     Duration `json:"window"`       // content seen within this window is not repeated
+	MaxPerUser int      `json:"max_per_user"` // entries kept per user
+}
+
+// DeliveriesConfig configures how long delivered content can still be voted on
+// or saved as a favorite
+type DeliveriesConfig struct {
+	Retention  Duration `json:"retention"`    // deliveries older than this are dropped
+	MaxPerChat int      `json:"max_per_chat"` // most recent deliveries kept per chat
+}
+
+// PrefetchConfig configures the per-mood buffer of ready content
+type PrefetchConfig struct {
+	Enabled        bool     `json:"enabled"`
+	BufferSize     int      `json:"buffer_size"`     // items kept ready per mood
+	RefillInterval Duration `json:"refill_interval"` // at most one refill fetch per mood per interval
+	FetchTimeout   Duration `json:"fetch_timeout"`
+}
+
+// MoodConfig binds a mood button to ordered chains of content and image providers
+type MoodConfig struct {
+	Name           string   `json:"name"`
+	Label          string   `json:"label"`
+	Emoji          string   `json:"emoji"`
+	ImageQuery     string   `json:"image_query"`
+	Providers      []string `json:"providers"`          // tried in order until one returns content
+	ImageProviders []string `json:"image_providers"`    // tried in order until one returns an image; empty for text only
+	Provider       string   `json:"provider,omitempty"` // deprecated: a single provider, read as a one-entry chain
+}
+
+// LanguageConfig is a language users can choose. UI strings come from
+// i18n/locales/<code>.json when it exists, English otherwise.
+type LanguageConfig struct {
+	Code      string `json:"code"`      // ISO 639-1 code, also used for machine translation
+	Name      string `json:"name"`      // native name, e.g. "हिंदी"
+	Flag      string `json:"flag"`      // emoji shown next to the name
+	Direction string `json:"direction"` // ltr or rtl
+}
+
+// defaultImageProviders returns the image chain for moods that don't list their own
+func defaultImageProviders() []string {
+	return []string{"unsplash", "local"}
+}
+
+// Default returns the built-in configuration
+func Default() Config {
+	return Config{
+		DataDir: "user_data",
+		Telegram: TelegramConfig{
+			APIEndpoint:     "https://api.telegram.org/bot%s/%s",
+			Mode:            "polling",
+			WebhookAddr:     ":8080",
+			WebhookPath:     "/telegram/webhook",
+			ShutdownTimeout: Duration{20 * time.Second},
+		},
+		Workers:   WorkersConfig{Count: 8, QueueSize: 256},
+		RateLimit: RateLimitConfig{Limit: 30, Window: Duration{time.Minute}},
+		Handlers: HandlersConfig{
+			ContentTimeout: Duration{8 * time.Second},
+			UITimeout:      Duration{5 * time.Second},
+			Repicks:        3,
+			HistorySize:    10,
+			AdminIDs:       []int64{},
+		},
+		APIs: APIsConfig{
+			ZenQuotes:    APIConfig{BaseURL: "https://zenquotes.io", Timeout: Duration{6 * time.Second}},
+			JokeAPI:      APIConfig{BaseURL: "https://official-joke-api.appspot.com", Timeout: Duration{6 * time.Second}},
+			UselessFacts: APIConfig{BaseURL: "https://uselessfacts.jsph.pl", Timeout: Duration{6 * time.Second}},
+			DummyJSON:    APIConfig{BaseURL: "https://dummyjson.com", Timeout: Duration{6 * time.Second}},
+			Unsplash: UnsplashConfig{
+				APIConfig: APIConfig{BaseURL: "https://api.unsplash.com", Timeout: Duration{6 * time.Second}},
+				AppName:   "moodbot",
+			},
+		},
+		Translation: TranslationConfig{
+			Backend:   "mymemory",
+			Fallbacks: []string{},
+			MyMemory: TranslationAPIConfig{
+				APIConfig:  APIConfig{BaseURL: "https://api.mymemory.translated.net", Timeout: Duration{10 * time.Second}},
+				DailyQuota: 5000, // MyMemory's anonymous limit
+			},
+			LibreTranslate: TranslationAPIConfig{
+				APIConfig: APIConfig{Timeout: Duration{10 * time.Second}},
+			},
+			CacheSize: 5000,
+		},
+		Resilience: ResilienceConfig{
+			Retry: RetryConfig{
+				MaxAttempts: 3,
+				BaseDelay:   Duration{200 * time.Millisecond},
+				MaxDelay:    Duration{2 * time.Second},
+			},
+			Breaker: BreakerConfig{
+				FailureThreshold: 3,
+				Cooldown:         Duration{30 * time.Second},
+			},
+		},
+		Images: ImagesConfig{LocalDir: "images"},
+		History: HistoryConfig{
+			Window:     Duration{7 * 24 * time.Hour},
+			MaxPerUser: 200,
+		},
+		Deliveries: DeliveriesConfig{
+			Retention:  Duration{30 * 24 * time.Hour},
+			MaxPerChat: 500,
+		},
+		Prefetch: PrefetchConfig{
+			Enabled:        true,
+			BufferSize:     2,
+			RefillInterval: Duration{5 * time.Second},
+			FetchTimeout:   Duration{15 * time.Second},
+		},
+		Moods: []MoodConfig{
+			{Name: "funny", Label: "Funny", Emoji: "😂", ImageQuery: "funny", Providers: []string{"jokeapi"}, ImageProviders: defaultImageProviders()},
+			{Name: "inspiring", Label: "Inspiring", Emoji: "💡", ImageQuery: "inspiration", Providers: []string{"zenquotes", "dummyjson"}, ImageProviders: defaultImageProviders()},
+			{Name: "educational", Label: "Educational", Emoji: "📚", ImageQuery: "books", Providers: []string{"uselessfacts"}, ImageProviders: defaultImageProviders()},
+			{Name: "relaxing", Label: "Relaxing", Emoji: "🌿", ImageQuery: "nature", Providers: []string{"zenquotes", "dummyjson"}, ImageProviders: defaultImageProviders()},
+			{Name: "adventurous", Label: "Adventurous", Emoji: "🌟", ImageQuery: "adventure", Providers: []string{"uselessfacts"}, ImageProviders: defaultImageProviders()},
+			{Name: "thoughtful", Label: "Thoughtful", Emoji: "🤔", ImageQuery: "meditation", Providers: []string{"zenquotes", "dummyjson"}, ImageProviders: defaultImageProviders()},
+		},
+		Languages: []LanguageConfig{
+			{Code: "en", Name: "English", Flag: "🇺🇸", Direction: "ltr"},
+			{Code: "hi", Name: "हिंदी", Flag: "🇮🇳", Direction: "ltr"},
+			{Code: "ta", Name: "தமிழ்", Flag: "🇮🇳", Direction: "ltr"},
+		},
+	}
+}
+
+// Load reads the config file at path (if any) over the defaults and applies
+// environment variable overrides. Callers should Validate the result once
+// they have applied their own overrides.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("error reading config file: %v", err)
+		}
+		// Moods and languages lists in the file replace the defaults rather than
+		// merging with them entry by entry
+		cfg.Moods = nil
+		cfg.Languages = nil
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("error parsing config file %s: %v", path, err)
+		}
+		if cfg.Moods == nil {
+			cfg.Moods = Default().Moods
+		}
+		if cfg.Languages == nil {
+			cfg.Languages = Default().Languages
+		}
+		for i, lang := range cfg.Languages {
+			if lang.Direction == "" {
+				cfg.Languages[i].Direction = "ltr"
+			}
+		}
+		for i, mood := range cfg.Moods {
+			if len(mood.Providers) == 0 && mood.Provider != "" {
+				cfg.Moods[i].Providers = []string{mood.Provider}
+			}
+			cfg.Moods[i].Provider = ""
+			// Configs written before image providers existed keep using Unsplash
+			if mood.ImageProviders == nil {
+				cfg.Moods[i].ImageProviders = defaultImageProviders()
+			}
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides config values from environment variables
+func applyEnv(cfg *Config) error {
+	stringVars := map[string]*string{
+		"TELEGRAM_BOT_TOKEN":          &cfg.Telegram.Token,
+		"WEBHOOK_URL":                 &cfg.Telegram.WebhookURL,
+		"WEBHOOK_SECRET":              &cfg.Telegram.WebhookSecret,
+		"UNSPLASH_ACCESS_KEY":         &cfg.APIs.Unsplash.AccessKey,
+		"MOODBOT_DATA_DIR":            &cfg.DataDir,
+		"MOODBOT_MODE":                &cfg.Telegram.Mode,
+		"MOODBOT_WEBHOOK_ADDR":        &cfg.Telegram.WebhookAddr,
+		"MOODBOT_WEBHOOK_PATH":        &cfg.Telegram.WebhookPath,
+		"MOODBOT_ZENQUOTES_URL":       &cfg.APIs.ZenQuotes.BaseURL,
+		"MOODBOT_JOKEAPI_URL":         &cfg.APIs.JokeAPI.BaseURL,
+		"MOODBOT_USELESSFACTS_URL":    &cfg.APIs.UselessFacts.BaseURL,
+		"MOODBOT_DUMMYJSON_URL":       &cfg.APIs.DummyJSON.BaseURL,
+		"MOODBOT_UNSPLASH_URL":        &cfg.APIs.Unsplash.BaseURL,
+		"MOODBOT_IMAGES_DIR":          &cfg.Images.LocalDir,
+		"MOODBOT_MYMEMORY_URL":        &cfg.Translation.MyMemory.BaseURL,
+		"MOODBOT_TRANSLATION_BACKEND": &cfg.Translation.Backend,
+		"MOODBOT_LIBRETRANSLATE_URL":  &cfg.Translation.LibreTranslate.BaseURL,
+		"LIBRETRANSLATE_API_KEY":      &cfg.Translation.LibreTranslate.APIKey,
+		"MOODBOT_TELEGRAM_API":        &cfg.Telegram.APIEndpoint,
+	}
+	for name, target := range stringVars {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = value
+		}
+	}
+
+	intVars := map[string]*int{
+		"MOODBOT_WORKERS":         &cfg.Workers.Count,
+		"MOODBOT_QUEUE_SIZE":      &cfg.Workers.QueueSize,
+		"MOODBOT_RATE_LIMIT":      &cfg.RateLimit.Limit,
+		"MOODBOT_PREFETCH_BUFFER": &cfg.Prefetch.BufferSize,
+		"MOODBOT_RETRY_ATTEMPTS":  &cfg.Resilience.Retry.MaxAttempts,
+	}
+	for name, target := range intVars {
+		if value, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be a number: %v", name, err)
+			}
+			*target = n
+		}
+	}
+
+	boolVars := map[string]*bool{
+		"MOODBOT_PREFETCH":       &cfg.Prefetch.Enabled,
+		"MOODBOT_CORPUS_PRIMARY": &cfg.Corpus.Primary,
+	}
+	for name, target := range boolVars {
+		if value, ok := os.LookupEnv(name); ok {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false: %v", name, err)
+			}
+			*target = b
+		}
+	}
+
+	// MOODBOT_API_TIMEOUT sets the timeout of every content API at once
+	durationVars := map[string][]*Duration{
+		"MOODBOT_SHUTDOWN_TIMEOUT":  {&cfg.Telegram.ShutdownTimeout},
+		"MOODBOT_CONTENT_TIMEOUT":   {&cfg.Handlers.ContentTimeout},
+		"MOODBOT_UI_TIMEOUT":        {&cfg.Handlers.UITimeout},
+		"MOODBOT_PREFETCH_INTERVAL": {&cfg.Prefetch.RefillInterval},
+		"MOODBOT_TRANSLATE_TIMEOUT": {&cfg.Translation.MyMemory.Timeout, &cfg.Translation.LibreTranslate.Timeout},
+		"MOODBOT_BREAKER_COOLDOWN":  {&cfg.Resilience.Breaker.Cooldown},
+		"MOODBOT_HISTORY_WINDOW":    {&cfg.History.Window},
+		"MOODBOT_API_TIMEOUT": {
+			&cfg.APIs.ZenQuotes.Timeout,
+			&cfg.APIs.JokeAPI.Timeout,
+			&cfg.APIs.UselessFacts.Timeout,
+			&cfg.APIs.DummyJSON.Timeout,
+			&cfg.APIs.Unsplash.Timeout,
+		},
+	}
+	// MOODBOT_TRANSLATION_FALLBACKS is a comma-separated list of backends
+	if value, ok := os.LookupEnv("MOODBOT_TRANSLATION_FALLBACKS"); ok {
+		cfg.Translation.Fallbacks = []string{}
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				cfg.Translation.Fallbacks = append(cfg.Translation.Fallbacks, field)
+			}
+		}
+	}
+	// MOODBOT_ADMIN_IDS is a comma-separated list of Telegram user IDs
+	if value, ok := os.LookupEnv("MOODBOT_ADMIN_IDS"); ok {
+		cfg.Handlers.AdminIDs = []int64{}
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return fmt.Errorf("MOODBOT_ADMIN_IDS must be a comma-separated list of user IDs: %v", err)
+			}
+			cfg.Handlers.AdminIDs = append(cfg.Handlers.AdminIDs, id)
+		}
+	}
+
+	for name, targets := range durationVars {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration like \"6s\": %v", name, err)
+		}
+		for _, target := range targets {
+			target.Duration = d
+		}
+	}
+
+	return nil
+}
+
+// languageCodePattern matches ISO 639 codes such as "hi" or "fil"
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// moodNamePattern keeps mood names safe to embed in callback data
+var moodNamePattern = regexp.MustCompile(`^[a-z0-9]{1,20}$`)
+
+// Validate checks the configuration for mistakes that would only show up at runtime
+func (cfg Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if cfg.Telegram.Token == "" {
+		add("telegram.token is required (set TELEGRAM_BOT_TOKEN)")
+	}
+	if strings.Count(cfg.Telegram.APIEndpoint, "%s") != 2 {
+		add("telegram.api_endpoint must contain %%s for the token and the method")
+	}
+	if cfg.DataDir == "" {
+		add("data_dir is required")
+	}
+
+	switch cfg.Telegram.Mode {
+	case "polling":
+	case "webhook":
+		if cfg.Telegram.WebhookAddr == "" {
+			add("telegram.webhook_addr is required in webhook mode")
+		}
+		if !strings.HasPrefix(cfg.Telegram.WebhookPath, "/") {
+			add("telegram.webhook_path must start with /")
+		}
+		if cfg.Telegram.WebhookURL != "" {
+			checkURL(add, "telegram.webhook_url", cfg.Telegram.WebhookURL)
+		}
+	default:
+		add("telegram.mode must be polling or webhook, got %q", cfg.Telegram.Mode)
+	}
+	checkPositive(add, "telegram.shutdown_timeout", cfg.Telegram.ShutdownTimeout)
+
+	if cfg.Workers.Count < 1 {
+		add("workers.count must be at least 1")
+	}
+	if cfg.Workers.QueueSize < 1 {
+		add("workers.queue_size must be at least 1")
+	}
+	if cfg.RateLimit.Limit < 1 {
+		add("rate_limit.limit must be at least 1")
+	}
+	checkPositive(add, "rate_limit.window", cfg.RateLimit.Window)
+	if cfg.APIs.Unsplash.AppName == "" {
+		add("apis.unsplash.app_name is required for attribution links")
+	}
+	checkPositive(add, "handlers.content_timeout", cfg.Handlers.ContentTimeout)
+	checkPositive(add, "handlers.ui_timeout", cfg.Handlers.UITimeout)
+	if cfg.Handlers.Repicks < 0 {
+		add("handlers.repicks must not be negative")
+	}
+	if cfg.Handlers.HistorySize < 1 {
+		add("handlers.history_size must be at least 1")
+	}
+	if cfg.Translation.CacheSize < 1 {
+		add("translation.cache_size must be at least 1")
+	}
+	translationAPIs := map[string]TranslationAPIConfig{
+		"mymemory":       cfg.Translation.MyMemory,
+		"libretranslate": cfg.Translation.LibreTranslate,
+	}
+	usedBackends := make(map[string]bool)
+	for i, name := range append([]string{cfg.Translation.Backend}, cfg.Translation.Fallbacks...) {
+		field := "translation.backend"
+		if i > 0 {
+			field = fmt.Sprintf("translation.fallbacks[%d]", i-1)
+		}
+		if !isTranslationBackend(name) {
+			add("%s must be one of %s, got %q", field, strings.Join(TranslationBackends, ", "), name)
+			continue
+		}
+		if usedBackends[name] {
+			add("%s %q is duplicated", field, name)
+		}
+		usedBackends[name] = true
+
+		api, exists := translationAPIs[name]
+		if !exists {
+			continue
+		}
+		checkURL(add, "translation."+name+".base_url", api.BaseURL)
+		checkPositive(add, "translation."+name+".timeout", api.Timeout)
+		if api.DailyQuota < 0 {
+			add("translation.%s.daily_quota must not be negative", name)
+		}
+	}
+	checkPositive(add, "history.window", cfg.History.Window)
+	if cfg.History.MaxPerUser < 1 {
+		add("history.max_per_user must be at least 1")
+	}
+	checkPositive(add, "deliveries.retention", cfg.Deliveries.Retention)
+	if cfg.Deliveries.MaxPerChat < 1 {
+		add("deliveries.max_per_chat must be at least 1")
+	}
+
+	apis := []struct {
+		name string
+		api  APIConfig
+	}{
+		{"apis.zenquotes", cfg.APIs.ZenQuotes},
+		{"apis.jokeapi", cfg.APIs.JokeAPI},
+		{"apis.uselessfacts", cfg.APIs.UselessFacts},
+		{"apis.dummyjson", cfg.APIs.DummyJSON},
+		{"apis.unsplash", cfg.APIs.Unsplash.APIConfig},
+	}
+	for _, a := range apis {
+		checkURL(add, a.name+".base_url", a.api.BaseURL)
+		checkPositive(add, a.name+".timeout", a.api.Timeout)
+	}
+
+	if cfg.Resilience.Retry.MaxAttempts < 1 {
+		add("resilience.retry.max_attempts must be at least 1")
+	}
+	checkPositive(add, "resilience.retry.base_delay", cfg.Resilience.Retry.BaseDelay)
+	if cfg.Resilience.Retry.MaxDelay.Duration < cfg.Resilience.Retry.BaseDelay.Duration {
+		add("resilience.retry.max_delay must not be less than base_delay")
+	}
+	if cfg.Resilience.Breaker.FailureThreshold < 1 {
+		add("resilience.breaker.failure_threshold must be at least 1")
+	}
+	checkPositive(add, "resilience.breaker.cooldown", cfg.Resilience.Breaker.Cooldown)
+
+	if cfg.Prefetch.Enabled {
+		if cfg.Prefetch.BufferSize < 1 {
+			add("prefetch.buffer_size must be at least 1 when prefetching is enabled")
+		}
+		checkPositive(add, "prefetch.refill_interval", cfg.Prefetch.RefillInterval)
+		checkPositive(add, "prefetch.fetch_timeout", cfg.Prefetch.FetchTimeout)
+	}
+
+	if len(cfg.Moods) == 0 {
+		add("at least one mood is required")
+	}
+	seen := make(map[string]bool)
+	for i, mood := range cfg.Moods {
+		if !moodNamePattern.MatchString(mood.Name) {
+			add("moods[%d].name %q must be 1-20 lowercase letters or digits", i, mood.Name)
+		}
+		if seen[mood.Name] {
+			add("moods[%d].name %q is duplicated", i, mood.Name)
+		}
+		seen[mood.Name] = true
+		if mood.Label == "" {
+			add("moods[%d].label is required", i)
+		}
+		if len(mood.Providers) == 0 {
+			add("moods[%d].providers needs at least one provider", i)
+		}
+	}
+
+	hasEnglish := false
+	seenLanguages := make(map[string]bool)
+	for i, lang := range cfg.Languages {
+		if !languageCodePattern.MatchString(lang.Code) {
+			add("languages[%d].code %q must be a 2-3 letter lowercase language code", i, lang.Code)
+		}
+		if seenLanguages[lang.Code] {
+			add("languages[%d].code %q is duplicated", i, lang.Code)
+		}
+		seenLanguages[lang.Code] = true
+		hasEnglish = hasEnglish || lang.Code == "en"
+		if lang.Name == "" {
+			add("languages[%d].name is required", i)
+		}
+		if lang.Direction != "ltr" && lang.Direction != "rtl" {
+			add("languages[%d].direction must be ltr or rtl, got %q", i, lang.Direction)
+		}
+	}
+	if !hasEnglish {
+		add("languages must include en, the language content is translated from")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// isTranslationBackend reports whether name is a known translation backend
+func isTranslationBackend(name string) bool {
+	for _, backend := range TranslationBackends {
+		if name == backend {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL reports a problem unless value is an absolute http(s) URL
+func checkURL(add func(string, ...interface{}), name, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("%s must be an absolute http(s) URL, got %q", name, value)
+	}
+}
+
+// checkPositive reports a problem unless d is greater than zero
+func checkPositive(add func(string, ...interface{}), name string, d Duration) {
+	if d.Duration <= 0 {
+		add("%s must be greater than zero", name)
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadLanguages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"languages": [
+		{"code": "en", "name": "English"},
+		{"code": "ur", "name": "اردو", "direction": "rtl"},
+		{"code": "bn", "name": "বাংলা"}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Omitted fields are not taken from the default at the same index
+	want := []LanguageConfig{
+		{Code: "en", Name: "English", Direction: "ltr"},
+		{Code: "ur", Name: "اردو", Direction: "rtl"},
+		{Code: "bn", Name: "বাংলা", Direction: "ltr"},
+	}
+	if !reflect.DeepEqual(cfg.Languages, want) {
+		t.Errorf("languages = %+v, want %+v", cfg.Languages, want)
+	}
+
+	// Without a languages list the defaults are kept
+	if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Languages, Default().Languages) {
+		t.Errorf("languages = %+v, want the defaults", cfg.Languages)
+	}
+}
@@ -0,0 +1,330 @@
+package corpus
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/storage"
+)
+
+//go:embed data/*.json
+var builtin embed.FS
+
+// Entry is a single quote, joke or fact in the corpus
+type Entry struct {
+	Kind      models.ContentKind `json:"kind"`
+	Text      string             `json:"text,omitempty"` // quotes and facts
+	Author    string             `json:"author,omitempty"`
+	Setup     string             `json:"setup,omitempty"` // jokes
+	Punchline string             `json:"punchline,omitempty"`
+	JokeType  string             `json:"joke_type,omitempty"` // jokes: general, programming, knock-knock...
+	Tags      []string           `json:"tags,omitempty"`
+	Language  string             `json:"language"`
+	SourceURL string             `json:"source_url,omitempty"`
+}
+
+// Content converts the entry to content attributed to provider
+func (e Entry) Content(provider string) models.Content {
+	return models.Content{
+		Kind:      e.Kind,
+		Body:      e.Text,
+		Author:    e.Author,
+		Setup:     e.Setup,
+		Punchline: e.Punchline,
+		JokeType:  e.JokeType,
+		SourceURL: e.SourceURL,
+		Language:  e.Language,
+		Provider:  provider,
+	}
+}
+
+// key identifies an entry for duplicate detection
+func (e Entry) key() string {
+	text := e.Text
+	if e.Kind == models.KindJoke {
+		text = e.Setup + "\n" + e.Punchline
+	}
+	return string(e.Kind) + "|" + e.Language + "|" + strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// Corpus is an in-memory collection of entries
+type Corpus struct {
+	entries []Entry
+	keys    map[string]bool
+	mutex   sync.RWMutex
+}
+
+// New creates a corpus holding entries, dropping duplicates
+func New(entries []Entry) *Corpus {
+	c := &Corpus{keys: make(map[string]bool)}
+	c.Add(entries)
+	return c
+}
+
+// Dir returns the directory imported corpus files are kept in
+func Dir(dataDir string) string {
+	return filepath.Join(dataDir, "corpus")
+}
+
+// Load loads the embedded corpus plus any imported files in dir
+func Load(dir string) (*Corpus, error) {
+	entries, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	c := New(entries)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	for _, path := range files {
+		imported, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		c.Add(imported)
+	}
+	return c, nil
+}
+
+// Builtin returns the entries embedded in the binary
+func Builtin() ([]Entry, error) {
+	files, err := builtin.ReadDir("data")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		data, err := builtin.ReadFile("data/" + f.Name())
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := Parse(data)
+		if err == nil {
+			err = Validate(parsed)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("embedded corpus %s: %v", f.Name(), err)
+		}
+		entries = append(entries, parsed...)
+	}
+	return entries, nil
+}
+
+// ReadFile reads and validates a corpus file
+func ReadFile(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Parse(data)
+	if err == nil {
+		err = Validate(entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// Parse parses a corpus file: a JSON array of entries. Unknown fields are rejected to catch typos.
+func Parse(data []byte) ([]Entry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var entries []Entry
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("error parsing corpus: %v", err)
+	}
+	return entries, nil
+}
+
+// languagePattern matches two-letter language codes such as "en" or "hi"
+var languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// Validate checks every entry and reports all problems at once
+func Validate(entries []Entry) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(entries) == 0 {
+		add("corpus has no entries")
+	}
+	seen := make(map[string]bool)
+	for i, e := range entries {
+		switch e.Kind {
+		case models.KindQuote:
+			if e.Text == "" || e.Author == "" {
+				add("entry %d: a quote needs text and author", i)
+			}
+		case models.KindFact:
+			if e.Text == "" {
+				add("entry %d: a fact needs text", i)
+			}
+		case models.KindJoke:
+			if e.Setup == "" || e.Punchline == "" {
+				add("entry %d: a joke needs setup and punchline", i)
+			}
+			if e.JokeType != "" && !validJokeType(e.JokeType) {
+				add("entry %d: joke_type must be one of %s, got %q", i, strings.Join(models.JokeTypes, ", "), e.JokeType)
+			}
+		default:
+			add("entry %d: kind must be quote, joke or fact, got %q", i, e.Kind)
+		}
+		if !languagePattern.MatchString(e.Language) {
+			add("entry %d: language must be a two-letter code, got %q", i, e.Language)
+		}
+		if seen[e.key()] {
+			add("entry %d: duplicate of an earlier entry", i)
+		}
+		seen[e.key()] = true
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validJokeType reports whether t is a known joke type
+func validJokeType(t string) bool {
+	for _, known := range models.JokeTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds entries that aren't already in the corpus and returns how many were added
+func (c *Corpus) Add(entries []Entry) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	added := 0
+	for _, e := range entries {
+		if c.keys[e.key()] {
+			continue
+		}
+		c.keys[e.key()] = true
+		c.entries = append(c.entries, e)
+		added++
+	}
+	return added
+}
+
+// Contains reports whether the corpus already has an entry like e
+func (c *Corpus) Contains(e Entry) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.keys[e.key()]
+}
+
+// Jokes returns the jokes of a type in a language
+func (c *Corpus) Jokes(jokeType, language string) []Entry {
+	var result []Entry
+	for _, e := range c.Entries(models.KindJoke, language) {
+		if e.JokeType == jokeType {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Entries returns the entries of a kind in a language
+func (c *Corpus) Entries(kind models.ContentKind, language string) []Entry {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var result []Entry
+	for _, e := range c.entries {
+		if e.Kind == kind && e.Language == language {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Languages returns the languages the corpus has entries of a kind in, sorted
+func (c *Corpus) Languages(kind models.ContentKind) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, e := range c.entries {
+		if e.Kind == kind && !seen[e.Language] {
+			seen[e.Language] = true
+			result = append(result, e.Language)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Len returns the number of entries
+func (c *Corpus) Len() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return len(c.entries)
+}
+
+// Import validates the corpus file at src and adds its new entries to c,
+// saving them to a file of the same name in dir. It returns how many entries
+// were added and how many were already in the corpus.
+func Import(c *Corpus, dir, src string) (added, duplicates int, err error) {
+	entries, err := ReadFile(src)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var fresh []Entry
+	for _, e := range entries {
+		if c.Contains(e) {
+			duplicates++
+			continue
+		}
+		fresh = append(fresh, e)
+	}
+	if len(fresh) == 0 {
+		return 0, duplicates, nil
+	}
+
+	// Importing a file twice under the same name appends to the earlier import
+	dest := filepath.Join(dir, filepath.Base(src))
+	var existing []Entry
+	if _, statErr := os.Stat(dest); statErr == nil {
+		if existing, err = ReadFile(dest); err != nil {
+			return 0, duplicates, err
+		}
+	}
+
+	data, err := json.MarshalIndent(append(existing, fresh...), "", "  ")
+	if err != nil {
+		return 0, duplicates, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, duplicates, fmt.Errorf("error creating corpus directory: %v", err)
+	}
+	if err := storage.WriteFileAtomic(dest, data, 0644); err != nil {
+		return 0, duplicates, fmt.Errorf("error saving corpus file: %v", err)
+	}
+	return c.Add(fresh), duplicates, nil
+}
@@ -0,0 +1,126 @@
+package corpus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/you/moodbot/models"
+)
+
+func TestBuiltinCorpus(t *testing.T) {
+	c, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []models.ContentKind{models.KindQuote, models.KindJoke, models.KindFact} {
+		if len(c.Entries(kind, "en")) == 0 {
+			t.Errorf("no built-in %s entries", kind)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	entries := []Entry{
+		{Kind: models.KindQuote, Text: "No author", Language: "en"},
+		{Kind: models.KindJoke, Setup: "No punchline", Language: "en"},
+		{Kind: "poem", Text: "Roses are red", Language: "en"},
+		{Kind: models.KindFact, Text: "Bad language", Language: "english"},
+		{Kind: models.KindFact, Text: "Twice", Language: "en"},
+		{Kind: models.KindFact, Text: "twice", Language: "en"},
+	}
+	err := Validate(entries)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"entry 0", "entry 1", "entry 2", "entry 3", "entry 5: duplicate"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	if _, err := Parse([]byte(`[{"kind": "fact", "txt": "typo", "language": "en"}]`)); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "corpus")
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(t.TempDir(), "extra.json")
+	data := `[
+		{"kind": "fact", "text": "Octopuses have three hearts.", "language": "en"},
+		{"kind": "fact", "text": "Cats have fewer toes on their back paws.", "language": "en"}
+	]`
+	if err := os.WriteFile(src, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	added, duplicates, err := Import(c, dir, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 || duplicates != 1 {
+		t.Errorf("added %d, skipped %d; want 1 and 1", added, duplicates)
+	}
+
+	// The imported entry survives a reload
+	reloaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Len() != c.Len() {
+		t.Errorf("reloaded corpus has %d entries, want %d", reloaded.Len(), c.Len())
+	}
+}
+
+func TestProviderFetch(t *testing.T) {
+	c := New([]Entry{{Kind: models.KindJoke, Setup: "Setup", Punchline: "Punchline", Language: "en"}})
+
+	content, err := NewProvider(c, models.KindJoke).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Setup != "Setup" || content.Provider != "corpus-jokes" {
+		t.Errorf("content = %+v", content)
+	}
+
+	if _, err := NewProvider(c, models.KindFact).Fetch(context.Background()); err == nil {
+		t.Error("expected an error when the corpus has no entries of the kind")
+	}
+}
+
+func TestProviderFetchLanguage(t *testing.T) {
+	c := New([]Entry{
+		{Kind: models.KindQuote, Text: "Patience.", Language: "en"},
+		{Kind: models.KindQuote, Text: "धैर्य।", Language: "hi"},
+		{Kind: models.KindJoke, Setup: "Setup", Punchline: "Punchline", Language: "en"},
+	})
+	quotes := NewProvider(c, models.KindQuote)
+
+	if got := quotes.Languages(); !reflect.DeepEqual(got, []string{"en", "hi"}) {
+		t.Errorf("Languages() = %v, want [en hi]", got)
+	}
+	content, err := quotes.FetchLanguage(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Body != "धैर्य।" || content.Language != "hi" {
+		t.Errorf("content = %+v, want the Hindi quote", content)
+	}
+	if content, _ := quotes.Fetch(context.Background()); content.Language != "en" {
+		t.Errorf("Fetch() language = %q, want English", content.Language)
+	}
+	if _, err := quotes.FetchLanguage(context.Background(), "ta"); err == nil {
+		t.Error("expected an error for a language without entries")
+	}
+}
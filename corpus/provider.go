@@ -0,0 +1,68 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/you/moodbot/models"
+)
+
+// ProviderName returns the name the corpus provider for a kind is registered under
+func ProviderName(kind models.ContentKind) string {
+	return "corpus-" + string(kind) + "s"
+}
+
+// Provider serves random corpus entries of one kind, in English unless asked for
+// another language. It never calls the network, so it works as the last link of
+// any fallback chain.
+type Provider struct {
+	corpus   *Corpus
+	kind     models.ContentKind
+	language string
+}
+
+// NewProvider creates a provider serving English entries of kind from c
+func NewProvider(c *Corpus, kind models.ContentKind) *Provider {
+	return &Provider{corpus: c, kind: kind, language: "en"}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string { return ProviderName(p.kind) }
+
+// Kind returns the kind of content this provider returns
+func (p *Provider) Kind() models.ContentKind { return p.kind }
+
+// Offline reports that the corpus is served without network calls
+func (p *Provider) Offline() bool { return true }
+
+// Fetch returns a random entry
+func (p *Provider) Fetch(ctx context.Context) (models.Content, error) {
+	return p.pick(p.corpus.Entries(p.kind, p.language), string(p.kind), p.language)
+}
+
+// Languages returns the languages the corpus has entries of the provider's kind in
+func (p *Provider) Languages() []string {
+	return p.corpus.Languages(p.kind)
+}
+
+// FetchLanguage returns a random entry written in lang
+func (p *Provider) FetchLanguage(ctx context.Context, lang string) (models.Content, error) {
+	return p.pick(p.corpus.Entries(p.kind, lang), string(p.kind), lang)
+}
+
+// FetchJokeType returns a random joke of the given type
+func (p *Provider) FetchJokeType(ctx context.Context, jokeType string) (models.Content, error) {
+	if p.kind != models.KindJoke {
+		return models.Content{}, fmt.Errorf("%s does not serve jokes", p.Name())
+	}
+	return p.pick(p.corpus.Jokes(jokeType, p.language), jokeType+" joke", p.language)
+}
+
+// pick returns a random entry, or an error naming what was wanted if there are none
+func (p *Provider) pick(entries []Entry, what, lang string) (models.Content, error) {
+	if len(entries) == 0 {
+		return models.Content{}, fmt.Errorf("corpus has no %s entries in %s", what, lang)
+	}
+	return entries[rand.Intn(len(entries))].Content(p.Name()), nil
+}
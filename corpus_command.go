@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/corpus"
+)
+
+const corpusUsage = `usage:
+  moodbot corpus validate FILE...             check corpus files without importing them
+  moodbot corpus import [-config PATH] FILE... validate files and add their new entries to the data dir`
+
+// runCorpusCommand runs "moodbot corpus ..." and returns the exit code
+func runCorpusCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, corpusUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		return validateCorpusFiles(args[1:])
+	case "import":
+		return importCorpusFiles(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, corpusUsage)
+		return 2
+	}
+}
+
+// validateCorpusFiles checks each file and reports every problem found
+func validateCorpusFiles(files []string) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, corpusUsage)
+		return 2
+	}
+
+	status := 0
+	for _, path := range files {
+		entries, err := corpus.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %d entries OK\n", path, len(entries))
+	}
+	return status
+}
+
+// importCorpusFiles adds the new entries of each file to the corpus in the data dir
+func importCorpusFiles(args []string) int {
+	fs := flag.NewFlagSet("corpus import", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to a JSON config file (defaults are used when empty)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, corpusUsage)
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	dir := corpus.Dir(cfg.DataDir)
+	c, err := corpus.Load(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	status := 0
+	for _, path := range fs.Args() {
+		added, duplicates, err := corpus.Import(c, dir, path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: imported %d entries, skipped %d already in the corpus\n", path, added, duplicates)
+	}
+	return status
+}
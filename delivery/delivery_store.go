@@ -0,0 +1,209 @@
+package delivery
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/storage"
+)
+
+// saveEvery is how many deliveries are recorded between writes to disk
+const saveEvery = 20
+
+// DeliveryStore keeps records of delivered content so callbacks can refer to it by ID
+type DeliveryStore struct {
+	deliveries map[string]models.Delivery
+	chats      map[int64][]string // delivery IDs per chat, oldest first
+	retention  time.Duration
+	maxPerChat int
+	unsaved    int
+	mutex      sync.RWMutex
+	saveMutex  sync.Mutex // orders writes to disk, which happen outside mutex
+	dataDir    string
+}
+
+// NewDeliveryStore creates a new delivery store persisted in dataDir, keeping
+// deliveries for the retention period and up to a limit per chat
+func NewDeliveryStore(dataDir string, cfg config.DeliveriesConfig) *DeliveryStore {
+	ds := &DeliveryStore{
+		deliveries: make(map[string]models.Delivery),
+		chats:      make(map[int64][]string),
+		retention:  cfg.Retention.Duration,
+		maxPerChat: cfg.MaxPerChat,
+		dataDir:    dataDir,
+	}
+
+	// Create data directory if it doesn't exist
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		fmt.Printf("Warning: Could not create delivery data directory: %v\n", err)
+	}
+
+	ds.loadDeliveries()
+
+	return ds
+}
+
+// Record stores a delivered item and returns its delivery ID
+func (ds *DeliveryStore) Record(chatID int64, mood string, content models.Content) string {
+	id, flush := ds.record(chatID, mood, content)
+	if flush {
+		if err := ds.saveDeliveries(); err != nil {
+			fmt.Printf("Error saving deliveries: %v\n", err)
+		}
+	}
+	return id
+}
+
+// record stores a delivered item and reports whether enough have built up to save
+func (ds *DeliveryStore) record(chatID int64, mood string, content models.Content) (string, bool) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	id := generateID()
+	for _, exists := ds.deliveries[id]; exists; _, exists = ds.deliveries[id] {
+		id = generateID()
+	}
+
+	ds.deliveries[id] = models.Delivery{
+		ID:          id,
+		ChatID:      chatID,
+		Mood:        mood,
+		Content:     content,
+		DeliveredAt: time.Now().Unix(),
+	}
+	ids := append(ds.chats[chatID], id)
+
+	// Drop the chat's oldest deliveries once over the limit
+	for len(ids) > ds.maxPerChat {
+		delete(ds.deliveries, ids[0])
+		ids = ids[1:]
+	}
+	ds.chats[chatID] = ids
+
+	ds.unsaved++
+	return id, ds.unsaved >= saveEvery
+}
+
+// Get returns the delivery with the given ID, unless it is past the retention period
+func (ds *DeliveryStore) Get(id string) (models.Delivery, bool) {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	d, exists := ds.deliveries[id]
+	if !exists || d.DeliveredAt < ds.cutoff() {
+		return models.Delivery{}, false
+	}
+	return d, true
+}
+
+// Close flushes deliveries to disk
+func (ds *DeliveryStore) Close() error {
+	return ds.saveDeliveries()
+}
+
+// cutoff returns the Unix time before which deliveries have expired
+func (ds *DeliveryStore) cutoff() int64 {
+	return time.Now().Add(-ds.retention).Unix()
+}
+
+// prune drops deliveries past the retention period
+func (ds *DeliveryStore) prune() {
+	cutoff := ds.cutoff()
+	for chatID, ids := range ds.chats {
+		for len(ids) > 0 && ds.deliveries[ids[0]].DeliveredAt < cutoff {
+			delete(ds.deliveries, ids[0])
+			ids = ids[1:]
+		}
+		if len(ids) == 0 {
+			delete(ds.chats, chatID)
+		} else {
+			ds.chats[chatID] = ids
+		}
+	}
+}
+
+// generateID creates a random ID for deliveries
+func generateID() string {
+	bytes := make([]byte, 4)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// getFilePath returns the file path for stored deliveries
+func (ds *DeliveryStore) getFilePath() string {
+	return filepath.Join(ds.dataDir, "deliveries.json")
+}
+
+// loadDeliveries loads deliveries from file
+func (ds *DeliveryStore) loadDeliveries() {
+	data, err := os.ReadFile(ds.getFilePath())
+	if err != nil {
+		// File doesn't exist, that's okay
+		return
+	}
+
+	var deliveries []models.Delivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		fmt.Printf("Warning: Could not load deliveries: %v\n", err)
+		return
+	}
+
+	for _, d := range deliveries {
+		ds.deliveries[d.ID] = d
+		ds.chats[d.ChatID] = append(ds.chats[d.ChatID], d.ID)
+	}
+	ds.prune()
+}
+
+// saveDeliveries drops expired deliveries and saves the rest to file, oldest first.
+// Only taking the snapshot holds the lock, so lookups aren't blocked while the file is written.
+func (ds *DeliveryStore) saveDeliveries() error {
+	ds.saveMutex.Lock()
+	defer ds.saveMutex.Unlock()
+
+	ds.mutex.Lock()
+	deliveries := ds.snapshot()
+	ds.unsaved = 0
+	ds.mutex.Unlock()
+
+	data, err := json.MarshalIndent(deliveries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling deliveries: %v", err)
+	}
+
+	if err := storage.WriteFileAtomic(ds.getFilePath(), data, 0644); err != nil {
+		return fmt.Errorf("error writing deliveries file: %v", err)
+	}
+
+	return nil
+}
+
+// snapshot drops expired deliveries and returns a copy of the rest, oldest first
+func (ds *DeliveryStore) snapshot() []models.Delivery {
+	ds.prune()
+
+	chatIDs := make([]int64, 0, len(ds.chats))
+	for chatID := range ds.chats {
+		chatIDs = append(chatIDs, chatID)
+	}
+	sort.Slice(chatIDs, func(i, j int) bool { return chatIDs[i] < chatIDs[j] })
+
+	deliveries := make([]models.Delivery, 0, len(ds.deliveries))
+	for _, chatID := range chatIDs {
+		for _, id := range ds.chats[chatID] {
+			deliveries = append(deliveries, ds.deliveries[id])
+		}
+	}
+	// Stable, so deliveries in the same second keep their order within a chat
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].DeliveredAt < deliveries[j].DeliveredAt })
+	return deliveries
+}
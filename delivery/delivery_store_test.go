@@ -0,0 +1,84 @@
+package delivery
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+)
+
+var testConfig = config.DeliveriesConfig{Retention: config.Duration{Duration: time.Hour}, MaxPerChat: 2}
+
+func TestRecordKeepsRecentPerChat(t *testing.T) {
+	ds := NewDeliveryStore(t.TempDir(), testConfig)
+	first := ds.Record(1, "funny", models.Content{Body: "one"})
+	second := ds.Record(1, "funny", models.Content{Body: "two"})
+	other := ds.Record(2, "funny", models.Content{Body: "other"})
+	third := ds.Record(1, "funny", models.Content{Body: "three"})
+
+	if _, exists := ds.Get(first); exists {
+		t.Error("the chat's oldest delivery should be dropped")
+	}
+	for _, id := range []string{second, third, other} {
+		if _, exists := ds.Get(id); !exists {
+			t.Errorf("delivery %s was dropped", id)
+		}
+	}
+}
+
+func TestDeliveriesExpire(t *testing.T) {
+	dataDir := t.TempDir()
+	ds := NewDeliveryStore(dataDir, testConfig)
+	old := ds.Record(1, "funny", models.Content{Body: "old"})
+	recent := ds.Record(1, "funny", models.Content{Body: "recent"})
+	d := ds.deliveries[old]
+	d.DeliveredAt = time.Now().Add(-2 * time.Hour).Unix()
+	ds.deliveries[old] = d
+
+	if _, exists := ds.Get(old); exists {
+		t.Error("an expired delivery should not be returned")
+	}
+
+	// Expired deliveries are not saved; the rest survive a restart
+	if err := ds.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := NewDeliveryStore(dataDir, testConfig)
+	if _, exists := reloaded.deliveries[old]; exists {
+		t.Error("an expired delivery was saved")
+	}
+	if d, exists := reloaded.Get(recent); !exists || d.Content.Body != "recent" {
+		t.Errorf("reloaded delivery = %+v, %v", d, exists)
+	}
+}
+
+func TestConcurrentRecordsAndSaves(t *testing.T) {
+	dataDir := t.TempDir()
+	cfg := config.DeliveriesConfig{Retention: testConfig.Retention, MaxPerChat: 100}
+	ds := NewDeliveryStore(dataDir, cfg)
+
+	// Saves take a snapshot and write it outside the lock while other chats keep recording
+	var wg sync.WaitGroup
+	for chatID := int64(1); chatID <= 4; chatID++ {
+		wg.Add(1)
+		go func(chatID int64) {
+			defer wg.Done()
+			for i := 0; i < saveEvery*2; i++ {
+				id := ds.Record(chatID, "funny", models.Content{Body: "joke"})
+				if _, exists := ds.Get(id); !exists {
+					t.Errorf("delivery %s missing right after it was recorded", id)
+				}
+			}
+		}(chatID)
+	}
+	wg.Wait()
+	if err := ds.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(NewDeliveryStore(dataDir, cfg).deliveries); n != 4*saveEvery*2 {
+		t.Errorf("reloaded %d deliveries, want %d", n, 4*saveEvery*2)
+	}
+}
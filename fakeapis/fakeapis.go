@@ -0,0 +1,361 @@
+package fakeapis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/you/moodbot/config"
+)
+
+// Mode controls how a fake server responds
+type Mode int
+
+const (
+	// OK serves canned responses
+	OK Mode = iota
+	// Error responds with HTTP 500
+	Error
+	// Slow waits SlowDelay (or until the client gives up) before responding normally
+	Slow
+	// Malformed responds with HTTP 200 and broken JSON
+	Malformed
+)
+
+// Server is an httptest stand-in for one upstream API
+type Server struct {
+	*httptest.Server
+
+	// SlowDelay is how long Slow mode waits before responding
+	SlowDelay time.Duration
+
+	mode  Mode
+	paths []string // request paths, in order
+	mutex sync.Mutex
+}
+
+// newServer starts a fake server; build returns the handler for canned responses
+func newServer(build func(s *Server) http.Handler) *Server {
+	s := &Server{SlowDelay: 10 * time.Second}
+	s.Server = httptest.NewServer(s.withMode(build(s)))
+	return s
+}
+
+// static adapts a handler that doesn't need the server
+func static(h http.HandlerFunc) func(s *Server) http.Handler {
+	return func(*Server) http.Handler { return h }
+}
+
+// withMode applies the server's current mode in front of the canned handler
+func (s *Server) withMode(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		mode, delay := s.mode, s.SlowDelay
+		s.mutex.Unlock()
+
+		switch mode {
+		case Error:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":"fake upstream failure"}`)
+			return
+		case Malformed:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"this is": not json`)
+			return
+		case Slow:
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetMode changes how the server responds to later requests
+func (s *Server) SetMode(mode Mode) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.mode = mode
+}
+
+// Requests returns how many requests the server has received
+func (s *Server) Requests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.paths)
+}
+
+// RequestsTo returns how many requests the server has received for paths starting with prefix
+func (s *Server) RequestsTo(prefix string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	n := 0
+	for _, path := range s.paths {
+		if strings.HasPrefix(path, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// APIs is a set of fake servers standing in for every upstream the bot talks to
+type APIs struct {
+	ZenQuotes      *Server
+	JokeAPI        *Server
+	UselessFacts   *Server
+	DummyJSON      *Server
+	Unsplash       *Server
+	MyMemory       *Server
+	LibreTranslate *Server
+	Telegram       *Telegram
+}
+
+// Start starts a fake server for every upstream API
+func Start() *APIs {
+	return &APIs{
+		ZenQuotes:      newServer(static(serveZenQuote)),
+		JokeAPI:        newServer(static(serveJoke)),
+		UselessFacts:   newServer(static(serveFact)),
+		DummyJSON:      newServer(static(serveDummyJSONQuote)),
+		Unsplash:       newServer(unsplashHandler),
+		MyMemory:       newServer(static(serveTranslation)),
+		LibreTranslate: newServer(static(serveLibreTranslate)),
+		Telegram:       newTelegram(),
+	}
+}
+
+// Configure points every API in cfg at the fake servers
+func (a *APIs) Configure(cfg *config.Config) {
+	cfg.APIs.ZenQuotes.BaseURL = a.ZenQuotes.URL
+	cfg.APIs.JokeAPI.BaseURL = a.JokeAPI.URL
+	cfg.APIs.UselessFacts.BaseURL = a.UselessFacts.URL
+	cfg.APIs.DummyJSON.BaseURL = a.DummyJSON.URL
+	cfg.APIs.Unsplash.BaseURL = a.Unsplash.URL
+	cfg.APIs.Unsplash.AccessKey = "fake-access-key"
+	cfg.Translation.MyMemory.BaseURL = a.MyMemory.URL
+	cfg.Translation.LibreTranslate.BaseURL = a.LibreTranslate.URL
+	cfg.Telegram.APIEndpoint = a.Telegram.URL + "/bot%s/%s"
+	if cfg.Telegram.Token == "" {
+		cfg.Telegram.Token = "fake-token"
+	}
+}
+
+// Close shuts down every fake server
+func (a *APIs) Close() {
+	a.ZenQuotes.Close()
+	a.JokeAPI.Close()
+	a.UselessFacts.Close()
+	a.DummyJSON.Close()
+	a.Unsplash.Close()
+	a.MyMemory.Close()
+	a.LibreTranslate.Close()
+	a.Telegram.Close()
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+var quotes = [][2]string{
+	{"The best way out is always through.", "Robert Frost"},
+	{"Well done is better than well said.", "Benjamin Franklin"},
+	{"Act as if what you do makes a difference. It does.", "William James"},
+}
+
+// serveZenQuote serves /api/random like ZenQuotes
+func serveZenQuote(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/random" {
+		http.NotFound(w, r)
+		return
+	}
+	q := quotes[rand.Intn(len(quotes))]
+	writeJSON(w, []map[string]string{{"q": q[0], "a": q[1], "h": ""}})
+}
+
+// serveDummyJSONQuote serves /quotes/random like DummyJSON
+func serveDummyJSONQuote(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/quotes/random" {
+		http.NotFound(w, r)
+		return
+	}
+	i := rand.Intn(len(quotes))
+	writeJSON(w, map[string]interface{}{"id": i + 1, "quote": quotes[i][0], "author": quotes[i][1]})
+}
+
+var jokes = []map[string]interface{}{
+	{"id": 1, "type": "general", "setup": "What do you call a fake noodle?", "punchline": "An impasta."},
+	{"id": 2, "type": "programming", "setup": "Why do programmers prefer dark mode?", "punchline": "Because light attracts bugs."},
+	{"id": 3, "type": "general", "setup": "Why don't eggs tell jokes?", "punchline": "They'd crack each other up."},
+	{"id": 4, "type": "knock-knock", "setup": "Knock knock. \n Who's there? \n Lettuce.", "punchline": "Lettuce who? \n Lettuce in, it's cold out here!"},
+}
+
+// serveJoke serves /jokes/random and /jokes/{type}/random like the Official Joke API
+func serveJoke(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/jokes/random" {
+		writeJSON(w, jokes[rand.Intn(len(jokes))])
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "jokes" || parts[2] != "random" {
+		http.NotFound(w, r)
+		return
+	}
+	// The type-specific endpoint returns an array, empty for unknown types
+	matching := []map[string]interface{}{}
+	for _, j := range jokes {
+		if j["type"] == parts[1] {
+			matching = append(matching, j)
+		}
+	}
+	if len(matching) > 1 {
+		matching = matching[rand.Intn(len(matching)):][:1]
+	}
+	writeJSON(w, matching)
+}
+
+var facts = []string{
+	"Honey never spoils.",
+	"Octopuses have three hearts.",
+	"Bananas are berries, but strawberries are not.",
+}
+
+// serveFact serves /api/v2/facts/random like Useless Facts
+func serveFact(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v2/facts/random" {
+		http.NotFound(w, r)
+		return
+	}
+	i := rand.Intn(len(facts))
+	writeJSON(w, map[string]string{
+		"id":         fmt.Sprintf("fact-%d", i),
+		"text":       facts[i],
+		"source":     "fakeapis",
+		"source_url": "https://example.com/facts",
+		"language":   r.URL.Query().Get("language"),
+	})
+}
+
+// translationPrefix marks fake translations with the target language, such as
+// "[hi] ", or the language pair when translating from a language other than English
+func translationPrefix(source, target string) string {
+	if source == "" || source == "en" {
+		return "[" + target + "] "
+	}
+	return "[" + source + "|" + target + "] "
+}
+
+// serveTranslation serves /get like MyMemory, prefixing the text with translationPrefix
+func serveTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/get" {
+		http.NotFound(w, r)
+		return
+	}
+	langpair := r.URL.Query().Get("langpair")
+	i := strings.Index(langpair, "|")
+	writeJSON(w, map[string]interface{}{
+		"responseData":   map[string]string{"translatedText": translationPrefix(langpair[:i], langpair[i+1:]) + r.URL.Query().Get("q")},
+		"responseStatus": 200,
+	})
+}
+
+// serveLibreTranslate serves /translate like LibreTranslate, prefixing the text with translationPrefix
+func serveLibreTranslate(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/translate" || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req struct {
+		Q      string `json:"q"`
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "Invalid request"})
+		return
+	}
+	writeJSON(w, map[string]string{"translatedText": translationPrefix(req.Source, req.Target) + req.Q})
+}
+
+// placeholderPNG is a tiny image served in place of Unsplash photos
+var placeholderPNG = func() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, color.RGBA{R: 255, G: 200, B: 0, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}()
+
+// unsplashHandler serves /photos/random and download tracking like Unsplash, and the photos it links to
+func unsplashHandler(s *Server) http.Handler {
+	unauthorized := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string][]string{"errors": {"OAuth error: The access token is invalid"}})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/photos/random", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Client-ID ") {
+			unauthorized(w)
+			return
+		}
+		// s.URL is read per request; it isn't known yet when the handler is built
+		query := r.URL.Query().Get("query")
+		image := s.URL + "/images/" + query + ".png"
+		writeJSON(w, map[string]interface{}{
+			"urls":            map[string]string{"regular": image, "small": image},
+			"alt_description": "a placeholder for " + query,
+			"user": map[string]interface{}{
+				"name":  "Fake Photographer",
+				"links": map[string]string{"html": "https://unsplash.com/@fake"},
+			},
+			"links": map[string]string{"download_location": s.URL + "/photos/fake-" + query + "/download"},
+		})
+	})
+	mux.HandleFunc("/photos/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/download") {
+			http.NotFound(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Client-ID ") {
+			unauthorized(w)
+			return
+		}
+		writeJSON(w, map[string]string{"url": s.URL + "/images/download.png"})
+	})
+	mux.HandleFunc("/images/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(placeholderPNG)
+	})
+
+	return mux
+}
+
+// logf logs fake API activity
+func logf(format string, args ...interface{}) {
+	log.Printf("[fakeapis] "+format, args...)
+}
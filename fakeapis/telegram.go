@@ -0,0 +1,110 @@
+package fakeapis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telegram is a stand-in for the Telegram Bot API that accepts every call and logs what the bot sends
+type Telegram struct {
+	*httptest.Server
+
+	calls     []Call
+	messageID int
+	mutex     sync.Mutex
+}
+
+// Call is a single Bot API method call
+type Call struct {
+	Method string
+	Params url.Values
+}
+
+// newTelegram starts a fake Bot API server
+func newTelegram() *Telegram {
+	t := &Telegram{}
+	t.Server = httptest.NewServer(http.HandlerFunc(t.serve))
+	return t
+}
+
+// Calls returns every method called so far, in order
+func (t *Telegram) Calls() []Call {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	result := make([]Call, len(t.calls))
+	copy(result, t.calls)
+	return result
+}
+
+// serve handles /bot{token}/{method}
+func (t *Telegram) serve(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") {
+		http.NotFound(w, r)
+		return
+	}
+	method := parts[1]
+
+	// Uploads are multipart, everything else is form-encoded
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		r.ParseMultipartForm(10 << 20)
+	} else {
+		r.ParseForm()
+	}
+
+	t.mutex.Lock()
+	t.calls = append(t.calls, Call{Method: method, Params: r.Form})
+	t.messageID++
+	messageID := t.messageID
+	t.mutex.Unlock()
+
+	switch method {
+	case "getMe":
+		writeJSON(w, ok(map[string]interface{}{"id": 1, "is_bot": true, "first_name": "MoodBot", "username": "moodbot_fake"}))
+	case "getUpdates":
+		// Nothing arrives over polling; updates can be posted to the webhook instead
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		writeJSON(w, ok([]interface{}{}))
+	case "sendMessage", "sendPhoto", "editMessageText", "editMessageCaption":
+		text := r.Form.Get("text")
+		if text == "" {
+			text = r.Form.Get("caption")
+		}
+		logf("%s to chat %s: %q", method, r.Form.Get("chat_id"), text)
+		chatID, _ := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+		message := map[string]interface{}{
+			"message_id": messageID,
+			"date":       time.Now().Unix(),
+			"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+			"text":       r.Form.Get("text"),
+			"caption":    r.Form.Get("caption"),
+		}
+		if method == "sendPhoto" {
+			message["photo"] = []map[string]interface{}{
+				{"file_id": "fake-file-" + strconv.Itoa(messageID), "file_unique_id": "u" + strconv.Itoa(messageID), "width": 8, "height": 8},
+			}
+		}
+		writeJSON(w, ok(message))
+	case "answerCallbackQuery":
+		if text := r.Form.Get("text"); text != "" {
+			logf("answerCallbackQuery: %q", text)
+		}
+		writeJSON(w, ok(true))
+	default:
+		writeJSON(w, ok(true))
+	}
+}
+
+// ok wraps a result in a successful Bot API response
+func ok(result interface{}) map[string]interface{} {
+	return map[string]interface{}{"ok": true, "result": result}
+}
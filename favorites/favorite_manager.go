@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/storage"
 )
 
 // FavoriteManager manages user favorites with file persistence
@@ -21,22 +22,20 @@ type FavoriteManager struct {
 }
 
 // NewFavoriteManager creates a new favorite manager with file persistence
-func NewFavoriteManager() *FavoriteManager {
-	dataDir := "user_data"
-	
-	// Create user_data directory if it doesn't exist
+func NewFavoriteManager(dataDir string) *FavoriteManager {
+	// Create data directory if it doesn't exist
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		fmt.Printf("Warning: Could not create user_data directory: %v\n", err)
+		fmt.Printf("Warning: Could not create favorites data directory: %v\n", err)
 	}
-	
+
 	return &FavoriteManager{
 		favorites: make(map[int64][]models.Favorite),
 		dataDir:   dataDir,
 	}
 }
 
-// AddFavorite adds a favorite for a user
-func (fm *FavoriteManager) AddFavorite(userID int64, contentType, content, author, setup, punchline, imageURL string) string {
+// AddFavorite adds a favorite for a user from delivered content
+func (fm *FavoriteManager) AddFavorite(userID int64, content models.Content) string {
 	fm.mutex.Lock()
 	defer fm.mutex.Unlock()
 
@@ -47,34 +46,41 @@ func (fm *FavoriteManager) AddFavorite(userID int64, contentType, content, autho
 
 	// Generate unique ID
 	id := generateID()
-	
+
 	favorite := models.Favorite{
 		ID:        id,
 		UserID:    userID,
-		Type:      contentType,
-		Content:   content,
-		Author:    author,
-		Setup:     setup,
-		Punchline: punchline,
-		ImageURL:  imageURL,
+		Type:      string(content.Kind),
+		Content:   content.Body,
+		Author:    content.Author,
+		Setup:     content.Setup,
+		Punchline: content.Punchline,
+		Image:     content.Image,
+		SourceURL: content.SourceURL,
+		Provider:  content.Provider,
+		Language:  content.Language,
 		SavedAt:   time.Now().Unix(),
 	}
+	if content.Kind == models.KindJoke {
+		favorite.Content = fmt.Sprintf("%s %s", content.Setup, content.Punchline)
+	}
 
 	// Add to cache
 	fm.favorites[userID] = append(fm.favorites[userID], favorite)
-	
+
 	// Save to file
 	if err := fm.saveUserFavorites(userID, fm.favorites[userID]); err != nil {
 		fmt.Printf("Error saving favorites for user %d: %v\n", userID, err)
 	}
-	
+
 	return id
 }
 
 // GetUserFavorites returns all favorites for a user
 func (fm *FavoriteManager) GetUserFavorites(userID int64) []models.Favorite {
-	fm.mutex.RLock()
-	defer fm.mutex.RUnlock()
+	// Loading fills the cache, so this needs the write lock
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
 
 	// Load from file if not in cache
 	if _, exists := fm.favorites[userID]; !exists {
@@ -105,12 +111,12 @@ func (fm *FavoriteManager) RemoveFavorite(userID int64, favoriteID string) bool
 			if fav.ID == favoriteID {
 				// Remove the favorite
 				fm.favorites[userID] = append(favorites[:i], favorites[i+1:]...)
-				
+
 				// Save to file
 				if err := fm.saveUserFavorites(userID, fm.favorites[userID]); err != nil {
 					fmt.Printf("Error saving favorites after removal for user %d: %v\n", userID, err)
 				}
-				
+
 				return true
 			}
 		}
@@ -120,8 +126,9 @@ func (fm *FavoriteManager) RemoveFavorite(userID int64, favoriteID string) bool
 
 // GetFavoriteCount returns the number of favorites for a user
 func (fm *FavoriteManager) GetFavoriteCount(userID int64) int {
-	fm.mutex.RLock()
-	defer fm.mutex.RUnlock()
+	// Loading fills the cache, so this needs the write lock
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
 
 	// Load from file if not in cache
 	if _, exists := fm.favorites[userID]; !exists {
@@ -139,22 +146,34 @@ func (fm *FavoriteManager) IsFavoriteCallback(data string) bool {
 	return len(data) > 9 && data[:9] == "favorite_"
 }
 
-// HandleFavoriteCallback processes favorite-related callbacks
-func (fm *FavoriteManager) HandleFavoriteCallback(data string, userID int64, contentType, content, author, setup, punchline, imageURL string) string {
-	if data == "favorite_add" {
-		id := fm.AddFavorite(userID, contentType, content, author, setup, punchline, imageURL)
-		return fmt.Sprintf("Added to favorites! (ID: %s)", id)
+// GetDeliveryID extracts the delivery ID from a favorite_add_{deliveryID} callback
+func (fm *FavoriteManager) GetDeliveryID(data string) (string, bool) {
+	if len(data) > 13 && data[:13] == "favorite_add_" {
+		return data[13:], true
 	}
-	
+	return "", false
+}
+
+// GetRemoveID extracts the favorite ID from a favorite_remove_{favoriteID} callback
+func (fm *FavoriteManager) GetRemoveID(data string) (string, bool) {
 	if len(data) > 16 && data[:16] == "favorite_remove_" {
-		favoriteID := data[16:]
-		if fm.RemoveFavorite(userID, favoriteID) {
-			return "Removed from favorites!"
+		return data[16:], true
+	}
+	return "", false
+}
+
+// Close flushes every cached user's favorites to disk
+func (fm *FavoriteManager) Close() error {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	var firstErr error
+	for userID, favorites := range fm.favorites {
+		if err := fm.saveUserFavorites(userID, favorites); err != nil && firstErr == nil {
+			firstErr = err
 		}
-		return "Favorite not found."
 	}
-	
-	return "Unknown favorite action."
+	return firstErr
 }
 
 // generateID creates a random ID for favorites
@@ -172,43 +191,43 @@ func (fm *FavoriteManager) getUserFilePath(userID int64) string {
 // loadUserFavorites loads favorites from file for a specific user
 func (fm *FavoriteManager) loadUserFavorites(userID int64) []models.Favorite {
 	filePath := fm.getUserFilePath(userID)
-	
+
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return []models.Favorite{}
 	}
-	
+
 	// Read file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		fmt.Printf("Error reading favorites file for user %d: %v\n", userID, err)
 		return []models.Favorite{}
 	}
-	
+
 	// Parse JSON
 	var favorites []models.Favorite
 	if err := json.Unmarshal(data, &favorites); err != nil {
 		fmt.Printf("Error parsing favorites file for user %d: %v\n", userID, err)
 		return []models.Favorite{}
 	}
-	
+
 	return favorites
 }
 
 // saveUserFavorites saves favorites to file for a specific user
 func (fm *FavoriteManager) saveUserFavorites(userID int64, favorites []models.Favorite) error {
 	filePath := fm.getUserFilePath(userID)
-	
+
 	// Convert to JSON
 	data, err := json.MarshalIndent(favorites, "", "  ")
 	if err != nil {
 		return fmt.Errorf("error marshaling favorites: %v", err)
 	}
-	
+
 	// Write to file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := storage.WriteFileAtomic(filePath, data, 0644); err != nil {
 		return fmt.Errorf("error writing favorites file: %v", err)
 	}
-	
+
 	return nil
-}
\ No newline at end of file
+}
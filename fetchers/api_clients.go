@@ -3,98 +3,383 @@ package fetchers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/health"
 	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/resilience"
 )
 
-// ZenQuotesFetcher handles fetching quotes from ZenQuotes API
-func FetchZenQuote(ctx context.Context) (string, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://zenquotes.io/api/random", nil)
-	client := &http.Client{Timeout: 6 * time.Second}
-	resp, err := client.Do(req)
+// Names of the built-in content providers
+const (
+	ZenQuotesName    = "zenquotes"
+	JokeAPIName      = "jokeapi"
+	UselessFactsName = "uselessfacts"
+	DummyJSONName    = "dummyjson"
+)
+
+// Names of the built-in image providers
+const (
+	UnsplashName    = "unsplash"
+	LocalImagesName = "local"
+)
+
+// checkStatus turns a non-2xx response into an error. Rate limits and server
+// errors may clear up on retry; other client errors are marked permanent.
+func checkStatus(name string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err := fmt.Errorf("%s: unexpected status %s", name, resp.Status)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return resilience.Permanent(err)
+}
+
+// ZenQuotesProvider fetches quotes from ZenQuotes API
+type ZenQuotesProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewZenQuotesProvider creates a new ZenQuotes provider
+func NewZenQuotesProvider(cfg config.APIConfig) *ZenQuotesProvider {
+	return &ZenQuotesProvider{
+		client:  &http.Client{Timeout: cfg.Timeout.Duration},
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+	}
+}
+
+// Name returns the provider name
+func (p *ZenQuotesProvider) Name() string { return ZenQuotesName }
+
+// Kind returns the kind of content this provider returns
+func (p *ZenQuotesProvider) Kind() models.ContentKind { return models.KindQuote }
+
+// Fetch fetches a random quote
+func (p *ZenQuotesProvider) Fetch(ctx context.Context) (models.Content, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/random", nil)
+	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", err
+		return models.Content{}, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(ZenQuotesName, resp); err != nil {
+		return models.Content{}, err
+	}
 
 	var q []models.ZenQuote
 	if err := json.NewDecoder(resp.Body).Decode(&q); err != nil {
-		return "", err
+		return models.Content{}, err
 	}
 	if len(q) == 0 {
-		return "", fmt.Errorf("no quote")
+		return models.Content{}, fmt.Errorf("no quote")
+	}
+	return models.Content{
+		Kind:      models.KindQuote,
+		Body:      q[0].Q,
+		Author:    q[0].A,
+		SourceURL: "https://zenquotes.io/",
+		Provider:  ZenQuotesName,
+	}, nil
+}
+
+// DummyJSONProvider fetches quotes from DummyJSON, a second quote source
+type DummyJSONProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewDummyJSONProvider creates a new DummyJSON quote provider
+func NewDummyJSONProvider(cfg config.APIConfig) *DummyJSONProvider {
+	return &DummyJSONProvider{
+		client:  &http.Client{Timeout: cfg.Timeout.Duration},
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
 	}
-	return fmt.Sprintf("\"%s\" — %s", q[0].Q, q[0].A), nil
 }
 
-// JokeFetcher handles fetching jokes from Official Joke API
-func FetchJoke(ctx context.Context) (string, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://official-joke-api.appspot.com/jokes/random", nil)
-	client := &http.Client{Timeout: 6 * time.Second}
-	resp, err := client.Do(req)
+// Name returns the provider name
+func (p *DummyJSONProvider) Name() string { return DummyJSONName }
+
+// Kind returns the kind of content this provider returns
+func (p *DummyJSONProvider) Kind() models.ContentKind { return models.KindQuote }
+
+// Fetch fetches a random quote
+func (p *DummyJSONProvider) Fetch(ctx context.Context) (models.Content, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/quotes/random", nil)
+	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", err
+		return models.Content{}, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(DummyJSONName, resp); err != nil {
+		return models.Content{}, err
+	}
+
+	var q models.DummyJSONQuote
+	if err := json.NewDecoder(resp.Body).Decode(&q); err != nil {
+		return models.Content{}, err
+	}
+	return models.Content{
+		Kind:     models.KindQuote,
+		Body:     q.Quote,
+		Author:   q.Author,
+		Provider: DummyJSONName,
+	}, nil
+}
+
+// JokeAPIProvider fetches jokes from Official Joke API
+type JokeAPIProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewJokeAPIProvider creates a new Official Joke API provider
+func NewJokeAPIProvider(cfg config.APIConfig) *JokeAPIProvider {
+	return &JokeAPIProvider{
+		client:  &http.Client{Timeout: cfg.Timeout.Duration},
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+	}
+}
+
+// Name returns the provider name
+func (p *JokeAPIProvider) Name() string { return JokeAPIName }
+
+// Kind returns the kind of content this provider returns
+func (p *JokeAPIProvider) Kind() models.ContentKind { return models.KindJoke }
+
+// Fetch fetches a random joke
+func (p *JokeAPIProvider) Fetch(ctx context.Context) (models.Content, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/jokes/random", nil)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return models.Content{}, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(JokeAPIName, resp); err != nil {
+		return models.Content{}, err
+	}
 
 	var j models.Joke
 	if err := json.NewDecoder(resp.Body).Decode(&j); err != nil {
-		return "", err
+		return models.Content{}, err
 	}
-	return fmt.Sprintf("%s\n\n%s", j.Setup, j.Punchline), nil
+	return jokeContent(j), nil
 }
 
-// FactFetcher handles fetching facts from Useless Facts API
-func FetchFact(ctx context.Context) (string, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://uselessfacts.jsph.pl/api/v2/facts/random?language=en", nil)
-	client := &http.Client{Timeout: 6 * time.Second}
-	resp, err := client.Do(req)
+// FetchJokeType fetches a random joke of the given type
+func (p *JokeAPIProvider) FetchJokeType(ctx context.Context, jokeType string) (models.Content, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/jokes/"+url.PathEscape(jokeType)+"/random", nil)
+	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", err
+		return models.Content{}, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(JokeAPIName, resp); err != nil {
+		return models.Content{}, err
+	}
+
+	// The type-specific endpoint returns an array holding one joke
+	var jokes []models.Joke
+	if err := json.NewDecoder(resp.Body).Decode(&jokes); err != nil {
+		return models.Content{}, err
+	}
+	if len(jokes) == 0 {
+		return models.Content{}, resilience.Permanent(fmt.Errorf("no %s jokes", jokeType))
+	}
+	return jokeContent(jokes[0]), nil
+}
+
+// jokeContent converts an Official Joke API joke to content
+func jokeContent(j models.Joke) models.Content {
+	return models.Content{
+		Kind:      models.KindJoke,
+		Setup:     j.Setup,
+		Punchline: j.Punchline,
+		JokeType:  j.Type,
+		Provider:  JokeAPIName,
+	}
+}
+
+// UselessFactsProvider fetches facts from Useless Facts API
+type UselessFactsProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewUselessFactsProvider creates a new Useless Facts provider
+func NewUselessFactsProvider(cfg config.APIConfig) *UselessFactsProvider {
+	return &UselessFactsProvider{
+		client:  &http.Client{Timeout: cfg.Timeout.Duration},
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+	}
+}
+
+// Name returns the provider name
+func (p *UselessFactsProvider) Name() string { return UselessFactsName }
+
+// Kind returns the kind of content this provider returns
+func (p *UselessFactsProvider) Kind() models.ContentKind { return models.KindFact }
+
+// Languages returns the languages Useless Facts serves
+func (p *UselessFactsProvider) Languages() []string { return []string{"en", "de"} }
+
+// Fetch fetches a random fact
+func (p *UselessFactsProvider) Fetch(ctx context.Context) (models.Content, error) {
+	return p.FetchLanguage(ctx, "en")
+}
+
+// FetchLanguage fetches a random fact written in lang
+func (p *UselessFactsProvider) FetchLanguage(ctx context.Context, lang string) (models.Content, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/v2/facts/random?language="+url.QueryEscape(lang), nil)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return models.Content{}, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(UselessFactsName, resp); err != nil {
+		return models.Content{}, err
+	}
 
 	var f models.Fact
 	if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
-		return "", err
+		return models.Content{}, err
+	}
+	if f.Language == "" {
+		f.Language = lang
 	}
-	
-	// Some responses use "text" field, so fallback:
-	if f.Text == "" {
-		// Try generic map
-		var m map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&m); err == nil {
-			if t, ok := m["text"].(string); ok {
-				f.Text = t
-			}
-		}
+	return models.Content{
+		Kind:      models.KindFact,
+		Body:      f.Text,
+		SourceURL: f.SourceURL,
+		Language:  f.Language,
+		Provider:  UselessFactsName,
+	}, nil
+}
+
+// UnsplashClient fetches images from Unsplash API
+type UnsplashClient struct {
+	client    *http.Client
+	baseURL   string
+	accessKey string
+	appName   string
+	health    *health.Tracker
+}
+
+// NewUnsplashClient creates a new Unsplash client
+func NewUnsplashClient(cfg config.UnsplashConfig) *UnsplashClient {
+	return &UnsplashClient{
+		client:    &http.Client{Timeout: cfg.Timeout.Duration},
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		accessKey: cfg.AccessKey,
+		appName:   cfg.AppName,
+		health:    health.NewTracker(),
+	}
+}
+
+// Configured reports whether an access key is set; without one every fetch fails
+func (c *UnsplashClient) Configured() bool { return c.accessKey != "" }
+
+// Health returns the outcome and latency of image fetches
+func (c *UnsplashClient) Health() health.Snapshot { return c.health.Snapshot() }
+
+// Name returns the name the client is registered under as an image provider
+func (c *UnsplashClient) Name() string { return UnsplashName }
+
+// ImageForMood fetches a random image matching the mood's image query
+func (c *UnsplashClient) ImageForMood(ctx context.Context, category models.ContentCategory) (models.Image, error) {
+	if category.ImageQuery == "" {
+		return models.Image{}, fmt.Errorf("unsplash: mood %s has no image query", category.Name)
 	}
-	return f.Text, nil
+	return c.FetchImage(ctx, category.ImageQuery)
 }
 
-// UnsplashFetcher handles fetching images from Unsplash API
-func FetchUnsplashImage(ctx context.Context, query string) (string, error) {
-	accessKey := os.Getenv("UNSPLASH_ACCESS_KEY")
-	if accessKey == "" {
-		return "", fmt.Errorf("UNSPLASH_ACCESS_KEY not set")
+// FetchImage fetches a random image matching query, with the photographer credit Unsplash requires
+func (c *UnsplashClient) FetchImage(ctx context.Context, query string) (models.Image, error) {
+	if c.accessKey == "" {
+		return models.Image{}, fmt.Errorf("UNSPLASH_ACCESS_KEY not set")
+	}
+
+	start := time.Now()
+	image, err := c.fetchImage(ctx, query)
+	if !errors.Is(err, context.Canceled) {
+		c.health.Record(time.Since(start), err)
 	}
+	return image, err
+}
 
-	url := fmt.Sprintf("https://api.unsplash.com/photos/random?query=%s&client_id=%s", query, accessKey)
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	client := &http.Client{Timeout: 6 * time.Second}
-	resp, err := client.Do(req)
+// fetchImage calls /photos/random
+func (c *UnsplashClient) fetchImage(ctx context.Context, query string) (models.Image, error) {
+	apiURL := fmt.Sprintf("%s/photos/random?query=%s", c.baseURL, url.QueryEscape(query))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	req.Header.Set("Authorization", "Client-ID "+c.accessKey)
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", err
+		return models.Image{}, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(UnsplashName, resp); err != nil {
+		return models.Image{}, err
+	}
 
 	var img models.UnsplashImage
 	if err := json.NewDecoder(resp.Body).Decode(&img); err != nil {
-		return "", err
+		return models.Image{}, err
+	}
+	if img.Urls.Small == "" {
+		return models.Image{}, fmt.Errorf("unsplash: no image URL")
+	}
+	return models.Image{
+		URL:     img.Urls.Small,
+		AltText: img.AltDescription,
+		Credit: &models.ImageCredit{
+			Name:       img.User.Name,
+			ProfileURL: c.referral(img.User.Links.HTML),
+			Source:     "Unsplash",
+			SourceURL:  c.referral("https://unsplash.com/"),
+		},
+		Provider:         UnsplashName,
+		DownloadLocation: img.Links.DownloadLocation,
+	}, nil
+}
+
+// TrackDownload pings an image's download location, which Unsplash requires whenever a photo is used
+func (c *UnsplashClient) TrackDownload(ctx context.Context, image models.Image) error {
+	if image.DownloadLocation == "" {
+		return nil
 	}
-	return img.Urls.Small, nil
-}
\ No newline at end of file
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, image.DownloadLocation, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Client-ID "+c.accessKey)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(UnsplashName, resp)
+}
+
+// referral adds the utm parameters Unsplash asks for on links back to it
+func (c *UnsplashClient) referral(link string) string {
+	if link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	q := u.Query()
+	q.Set("utm_source", c.appName)
+	q.Set("utm_medium", "referral")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
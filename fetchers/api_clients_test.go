@@ -0,0 +1,158 @@
+package fetchers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/fakeapis"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/resilience"
+)
+
+// newTestConfig starts the fake APIs and returns a config pointing at them
+func newTestConfig(t *testing.T) (config.Config, *fakeapis.APIs) {
+	t.Helper()
+
+	apis := fakeapis.Start()
+	t.Cleanup(apis.Close)
+
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	apis.Configure(&cfg)
+	return cfg, apis
+}
+
+func TestProvidersFetch(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+
+	providers := []ContentProvider{
+		NewZenQuotesProvider(cfg.APIs.ZenQuotes),
+		NewDummyJSONProvider(cfg.APIs.DummyJSON),
+		NewJokeAPIProvider(cfg.APIs.JokeAPI),
+		NewUselessFactsProvider(cfg.APIs.UselessFacts),
+	}
+	for _, p := range providers {
+		t.Run(p.Name(), func(t *testing.T) {
+			content, err := p.Fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if content.Kind != p.Kind() || content.Provider != p.Name() {
+				t.Errorf("content = %+v", content)
+			}
+			switch content.Kind {
+			case models.KindJoke:
+				if content.Setup == "" || content.Punchline == "" {
+					t.Errorf("joke missing setup or punchline: %+v", content)
+				}
+			case models.KindQuote:
+				if content.Body == "" || content.Author == "" {
+					t.Errorf("quote missing body or author: %+v", content)
+				}
+			default:
+				if content.Body == "" {
+					t.Errorf("empty body: %+v", content)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderMalformedResponse(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.ZenQuotes.SetMode(fakeapis.Malformed)
+
+	if _, err := NewZenQuotesProvider(cfg.APIs.ZenQuotes).Fetch(context.Background()); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
+
+func TestJokeAPIFetchJokeType(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	p := NewJokeAPIProvider(cfg.APIs.JokeAPI)
+
+	content, err := p.FetchJokeType(context.Background(), models.JokeProgramming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.JokeType != models.JokeProgramming || content.Setup == "" {
+		t.Errorf("content = %+v, want a programming joke", content)
+	}
+
+	if _, err := p.FetchJokeType(context.Background(), "limerick"); err == nil {
+		t.Error("expected an error for a type with no jokes")
+	}
+}
+
+func TestProviderErrorStatus(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.UselessFacts.SetMode(fakeapis.Error)
+
+	_, err := NewUselessFactsProvider(cfg.APIs.UselessFacts).Fetch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("err = %v, want an unexpected status error", err)
+	}
+	if resilience.IsPermanent(err) {
+		t.Error("a server error should be retryable")
+	}
+
+	cfg.APIs.UselessFacts.BaseURL = apis.UselessFacts.URL + "/missing"
+	apis.UselessFacts.SetMode(fakeapis.OK)
+	if _, err := NewUselessFactsProvider(cfg.APIs.UselessFacts).Fetch(context.Background()); !resilience.IsPermanent(err) {
+		t.Errorf("err = %v, want a permanent error for 404", err)
+	}
+}
+
+func TestProviderSlowResponse(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.JokeAPI.SetMode(fakeapis.Slow)
+	cfg.APIs.JokeAPI.Timeout = config.Duration{Duration: 50 * time.Millisecond}
+
+	start := time.Now()
+	if _, err := NewJokeAPIProvider(cfg.APIs.JokeAPI).Fetch(context.Background()); err == nil {
+		t.Error("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("fetch took %s, want it to give up after the client timeout", elapsed)
+	}
+}
+
+func TestUnsplashClientFetchImage(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+
+	client := NewUnsplashClient(cfg.APIs.Unsplash)
+	image, err := client.FetchImage(context.Background(), "nature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := apis.Unsplash.URL + "/images/nature.png"; image.URL != want {
+		t.Errorf("image URL = %q, want %q", image.URL, want)
+	}
+	if image.AltText != "a placeholder for nature" {
+		t.Errorf("alt text = %q", image.AltText)
+	}
+	wantCredit := models.ImageCredit{
+		Name:       "Fake Photographer",
+		ProfileURL: "https://unsplash.com/@fake?utm_medium=referral&utm_source=moodbot",
+		Source:     "Unsplash",
+		SourceURL:  "https://unsplash.com/?utm_medium=referral&utm_source=moodbot",
+	}
+	if image.Credit == nil || *image.Credit != wantCredit {
+		t.Errorf("credit = %+v, want %+v", image.Credit, wantCredit)
+	}
+
+	if err := client.TrackDownload(context.Background(), image); err != nil {
+		t.Fatal(err)
+	}
+	if got := apis.Unsplash.RequestsTo("/photos/fake-nature/download"); got != 1 {
+		t.Errorf("download tracked %d times, want 1", got)
+	}
+
+	cfg.APIs.Unsplash.AccessKey = ""
+	if _, err := NewUnsplashClient(cfg.APIs.Unsplash).FetchImage(context.Background(), "nature"); err == nil {
+		t.Error("expected an error without an access key")
+	}
+}
@@ -0,0 +1,70 @@
+package fetchers
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/you/moodbot/models"
+)
+
+// imageExtensions are the file types Telegram accepts as photos
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+}
+
+// LocalImageProvider picks images from a folder per mood, e.g. images/funny/*.jpg
+type LocalImageProvider struct {
+	dir string
+}
+
+// NewLocalImageProvider creates a provider reading mood folders under dir
+func NewLocalImageProvider(dir string) *LocalImageProvider {
+	return &LocalImageProvider{dir: dir}
+}
+
+// Name returns the name the provider is registered under
+func (p *LocalImageProvider) Name() string { return LocalImagesName }
+
+// Configured reports whether the image directory exists
+func (p *LocalImageProvider) Configured() bool {
+	info, err := os.Stat(p.dir)
+	return p.dir != "" && err == nil && info.IsDir()
+}
+
+// ImageForMood picks a random image file from the mood's folder. The folder
+// is read on every call so images can be added without a restart.
+func (p *LocalImageProvider) ImageForMood(ctx context.Context, category models.ContentCategory) (models.Image, error) {
+	if p.dir == "" {
+		return models.Image{}, fmt.Errorf("local images: no directory configured")
+	}
+
+	folder := filepath.Join(p.dir, category.Name)
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return models.Image{}, fmt.Errorf("local images: %v", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && !strings.HasPrefix(name, ".") && imageExtensions[strings.ToLower(filepath.Ext(name))] {
+			files = append(files, name)
+		}
+	}
+	if len(files) == 0 {
+		return models.Image{}, fmt.Errorf("local images: no images in %s", folder)
+	}
+
+	return models.Image{
+		Path:     filepath.Join(folder, files[rand.Intn(len(files))]),
+		Provider: LocalImagesName,
+	}, nil
+}
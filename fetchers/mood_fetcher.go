@@ -0,0 +1,215 @@
+package fetchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/you/moodbot/models"
+)
+
+// ErrNoNativeSource means no provider for a mood serves the requested language
+var ErrNoNativeSource = errors.New("no native source")
+
+// withoutImageKey marks a context whose fetches should skip the image
+type withoutImageKey struct{}
+
+// WithoutImage returns a context in which MoodFetcher fetches content only, so
+// callers that may discard the content can add an image later with AddImage
+func WithoutImage(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutImageKey{}, true)
+}
+
+// MoodFetcher fetches complete items for a mood: content from the mood's provider chain
+// plus an image from the first of its image providers that has one
+type MoodFetcher struct {
+	registry *Registry
+}
+
+// NewMoodFetcher creates a mood fetcher
+func NewMoodFetcher(registry *Registry) *MoodFetcher {
+	return &MoodFetcher{registry: registry}
+}
+
+// FetchMood fetches content for a mood, falling back along the mood's provider
+// chain until one returns content. A missing image is not an error.
+func (f *MoodFetcher) FetchMood(ctx context.Context, mood string) (models.Content, error) {
+	category, exists := f.registry.Mood(mood)
+	if !exists {
+		return models.Content{}, fmt.Errorf("unknown mood: %s", mood)
+	}
+	chain, err := f.registry.ProvidersForMood(mood)
+	if err != nil {
+		return models.Content{}, err
+	}
+
+	content, err := fetchFirst(ctx, chain, ContentProvider.Fetch)
+	if err != nil {
+		return models.Content{}, fmt.Errorf("no content for %s: %w", mood, err)
+	}
+	return f.withImage(ctx, category, content), nil
+}
+
+// FetchNative fetches content for a mood written in lang, using only the
+// providers in the mood's chain that serve lang natively. It returns
+// ErrNoNativeSource when none of them do, so callers can fall back to
+// translating FetchMood's content.
+func (f *MoodFetcher) FetchNative(ctx context.Context, mood, lang string) (models.Content, error) {
+	category, exists := f.registry.Mood(mood)
+	if !exists {
+		return models.Content{}, fmt.Errorf("unknown mood: %s", mood)
+	}
+	chain, err := f.registry.ProvidersForMood(mood)
+	if err != nil {
+		return models.Content{}, err
+	}
+
+	var native []ContentProvider
+	for _, p := range chain {
+		if _, ok := p.(LanguageProvider); ok && SupportsLanguage(p, lang) {
+			native = append(native, p)
+		}
+	}
+	if len(native) == 0 {
+		return models.Content{}, fmt.Errorf("%w: %s in %s", ErrNoNativeSource, mood, lang)
+	}
+
+	content, err := fetchFirst(ctx, native, func(p ContentProvider, ctx context.Context) (models.Content, error) {
+		return p.(LanguageProvider).FetchLanguage(ctx, lang)
+	})
+	if err != nil {
+		return models.Content{}, fmt.Errorf("no %s content for %s: %w", lang, mood, err)
+	}
+	return f.withImage(ctx, category, content), nil
+}
+
+// FetchJoke fetches a joke of the given type for a mood, using only the
+// providers in the mood's chain that support joke types
+func (f *MoodFetcher) FetchJoke(ctx context.Context, mood, jokeType string) (models.Content, error) {
+	category, exists := f.registry.Mood(mood)
+	if !exists {
+		return models.Content{}, fmt.Errorf("unknown mood: %s", mood)
+	}
+	chain, err := f.registry.ProvidersForMood(mood)
+	if err != nil {
+		return models.Content{}, err
+	}
+
+	var typed []ContentProvider
+	for _, p := range chain {
+		if _, ok := p.(JokeTypeProvider); ok && p.Kind() == models.KindJoke {
+			typed = append(typed, p)
+		}
+	}
+	if len(typed) == 0 {
+		return models.Content{}, fmt.Errorf("mood %s has no provider for joke types", mood)
+	}
+
+	content, err := fetchFirst(ctx, typed, func(p ContentProvider, ctx context.Context) (models.Content, error) {
+		return p.(JokeTypeProvider).FetchJokeType(ctx, jokeType)
+	})
+	if err != nil {
+		return models.Content{}, fmt.Errorf("no %s jokes for %s: %w", jokeType, mood, err)
+	}
+	return f.withImage(ctx, category, content), nil
+}
+
+// AddImage adds an image for the mood to content fetched WithoutImage.
+// Content that already has an image is returned as is.
+func (f *MoodFetcher) AddImage(ctx context.Context, mood string, content models.Content) models.Content {
+	category, exists := f.registry.Mood(mood)
+	if !exists || content.Image != nil {
+		return content
+	}
+	return f.withImage(context.WithValue(ctx, withoutImageKey{}, false), category, content)
+}
+
+// withImage adds an image from the mood's image providers to content, if any of them has one
+func (f *MoodFetcher) withImage(ctx context.Context, category models.ContentCategory, content models.Content) models.Content {
+	if skip, _ := ctx.Value(withoutImageKey{}).(bool); skip {
+		return content
+	}
+	chain, err := f.registry.ImageProvidersForMood(category.Name)
+	if err != nil {
+		return content
+	}
+	for _, provider := range chain {
+		if image, err := provider.ImageForMood(ctx, category); err == nil {
+			content.Image = &image
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return content
+}
+
+// TrackDownload tells the provider an image came from that it was shown, if
+// that provider needs to know
+func (f *MoodFetcher) TrackDownload(ctx context.Context, image models.Image) error {
+	name := image.Provider
+	if name == "" && image.DownloadLocation != "" {
+		// Saved before images recorded their provider, when only Unsplash had download locations
+		name = UnsplashName
+	}
+	p, exists := f.registry.ImageProvider(name)
+	if !exists {
+		return nil
+	}
+	if tracker, ok := p.(DownloadTracker); ok {
+		return tracker.TrackDownload(ctx, image)
+	}
+	return nil
+}
+
+// fetchFirst returns content from the first provider in chain that has some.
+// Each network provider, retries included, gets an even share of the time left
+// before ctx's deadline, so one that hangs can't starve the providers after it.
+// Offline providers need no network, so they are tried even once ctx is done.
+func fetchFirst(ctx context.Context, chain []ContentProvider, fetch func(p ContentProvider, ctx context.Context) (models.Content, error)) (models.Content, error) {
+	online := 0
+	for _, provider := range chain {
+		if !isOffline(provider) {
+			online++
+		}
+	}
+
+	var errs []error
+	for _, provider := range chain {
+		var providerCtx context.Context
+		var cancel context.CancelFunc
+		if isOffline(provider) {
+			providerCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+		} else {
+			if err := ctx.Err(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+				continue
+			}
+			providerCtx, cancel = ShareDeadline(ctx, online)
+			online--
+		}
+
+		content, err := fetch(provider, providerCtx)
+		cancel()
+		if err == nil && content.Body == "" && content.Setup == "" {
+			err = fmt.Errorf("%s returned empty content", provider.Name())
+		}
+		if err == nil {
+			return content, nil
+		}
+		errs = append(errs, err)
+	}
+	return models.Content{}, errors.Join(errs...)
+}
+
+// ShareDeadline returns a context for the next of n calls sharing ctx's deadline,
+// which ends after an even share of the time left
+func ShareDeadline(ctx context.Context, n int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || n <= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(n))
+}
@@ -0,0 +1,396 @@
+package fetchers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/corpus"
+	"github.com/you/moodbot/fakeapis"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/resilience"
+)
+
+// newFastRegistry builds the default registry with retry delays short enough for tests
+func newFastRegistry(t *testing.T, cfg config.Config) *Registry {
+	t.Helper()
+
+	cfg.Resilience.Retry.BaseDelay = config.Duration{Duration: time.Millisecond}
+	cfg.Resilience.Retry.MaxDelay = config.Duration{Duration: 5 * time.Millisecond}
+	registry, err := NewDefaultRegistry(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func TestFetchMoodFallsBack(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.ZenQuotes.SetMode(fakeapis.Error)
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+
+	content, err := fetcher.FetchMood(context.Background(), "inspiring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Provider != DummyJSONName {
+		t.Errorf("provider = %q, want the fallback %q", content.Provider, DummyJSONName)
+	}
+	if got, want := apis.ZenQuotes.Requests(), cfg.Resilience.Retry.MaxAttempts; got != want {
+		t.Errorf("zenquotes got %d requests, want %d attempts", got, want)
+	}
+}
+
+func TestFetchMoodFallsBackToCorpus(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.ZenQuotes.SetMode(fakeapis.Error)
+	apis.DummyJSON.SetMode(fakeapis.Malformed)
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+
+	content, err := fetcher.FetchMood(context.Background(), "inspiring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := corpus.ProviderName(models.KindQuote); content.Provider != want {
+		t.Errorf("provider = %q, want %q", content.Provider, want)
+	}
+}
+
+func TestFetchMoodSlowUpstreams(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.ZenQuotes.SetMode(fakeapis.Slow)
+	apis.DummyJSON.SetMode(fakeapis.Slow)
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+
+	// Hung upstreams use up their share of the deadline, not the whole of it
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	content, err := fetcher.FetchMood(ctx, "inspiring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := corpus.ProviderName(models.KindQuote); content.Provider != want {
+		t.Errorf("provider = %q, want %q", content.Provider, want)
+	}
+}
+
+func TestFetchMoodFallsBackToCorpusAfterDeadline(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	content, err := fetcher.FetchMood(ctx, "inspiring")
+	if err != nil {
+		t.Fatalf("FetchMood() = %v, want the corpus even with the caller's context done", err)
+	}
+	if want := corpus.ProviderName(models.KindQuote); content.Provider != want {
+		t.Errorf("provider = %q, want %q", content.Provider, want)
+	}
+}
+
+func TestFetchMoodAllProvidersFail(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.UselessFacts.SetMode(fakeapis.Error)
+	registry := newFastRegistry(t, cfg)
+	registry.AddMood(models.ContentCategory{Name: "facts", Providers: []string{UselessFactsName}})
+
+	if _, err := NewMoodFetcher(registry).FetchMood(context.Background(), "facts"); err == nil {
+		t.Error("expected an error when every provider fails")
+	}
+}
+
+func TestCorpusPrimary(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	cfg.Corpus.Primary = true
+	registry := newFastRegistry(t, cfg)
+
+	chain, err := registry.ProvidersForMood("funny")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain[0].Name() != corpus.ProviderName(models.KindJoke) || chain[len(chain)-1].Name() != JokeAPIName {
+		t.Errorf("chain starts with %s and ends with %s, want the corpus first", chain[0].Name(), chain[len(chain)-1].Name())
+	}
+
+	if _, err := NewMoodFetcher(registry).FetchMood(context.Background(), "funny"); err != nil {
+		t.Fatal(err)
+	}
+	if apis.JokeAPI.Requests() != 0 {
+		t.Error("the API should not be called while the corpus has content")
+	}
+}
+
+func TestCircuitOpensAfterFailures(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	apis.JokeAPI.SetMode(fakeapis.Error)
+	registry := newFastRegistry(t, cfg)
+	p, _ := registry.Provider(JokeAPIName)
+
+	for i := 0; i < cfg.Resilience.Breaker.FailureThreshold; i++ {
+		p.Fetch(context.Background())
+	}
+	before := apis.JokeAPI.Requests()
+
+	_, err := p.Fetch(context.Background())
+	if !errors.Is(err, resilience.ErrCircuitOpen) {
+		t.Errorf("err = %v, want the circuit to be open", err)
+	}
+	if apis.JokeAPI.Requests() != before {
+		t.Error("an open circuit should not call the upstream")
+	}
+	if state := p.(*ResilientProvider).Breaker().State(); state != resilience.Open {
+		t.Errorf("state = %s, want open", state)
+	}
+
+	// The mood still gets content from the corpus while the circuit is open
+	if _, err := NewMoodFetcher(registry).FetchMood(context.Background(), "funny"); err != nil {
+		t.Errorf("FetchMood() = %v, want the corpus fallback", err)
+	}
+}
+
+func TestPermanentErrorsKeepCircuitClosed(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	registry := newFastRegistry(t, cfg)
+	p, _ := registry.Provider(JokeAPIName)
+
+	for i := 0; i < cfg.Resilience.Breaker.FailureThreshold+1; i++ {
+		if _, err := p.(JokeTypeProvider).FetchJokeType(context.Background(), "limerick"); !resilience.IsPermanent(err) {
+			t.Fatalf("err = %v, want a permanent error for a type with no jokes", err)
+		}
+	}
+	if state := p.(*ResilientProvider).Breaker().State(); state != resilience.Closed {
+		t.Errorf("state = %s, want closed after requests with no results", state)
+	}
+}
+
+func TestDeadlineDuringBackoffKeepsCircuitClosed(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	cfg.Resilience.Retry.BaseDelay = config.Duration{Duration: time.Hour}
+	cfg.Resilience.Retry.MaxDelay = config.Duration{Duration: time.Hour}
+	apis.JokeAPI.SetMode(fakeapis.Error)
+	registry, err := NewDefaultRegistry(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := registry.Provider(JokeAPIName)
+
+	for i := 0; i < cfg.Resilience.Breaker.FailureThreshold+1; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := p.Fetch(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want the deadline to end the backoff", err)
+		}
+	}
+	if state := p.(*ResilientProvider).Breaker().State(); state != resilience.Closed {
+		t.Errorf("state = %s, want closed when callers' deadlines end the backoffs", state)
+	}
+}
+
+func TestCancelledTrialReleasesCircuit(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	cfg.Resilience.Breaker.Cooldown = config.Duration{Duration: 10 * time.Millisecond}
+	apis.JokeAPI.SetMode(fakeapis.Error)
+	registry := newFastRegistry(t, cfg)
+	p, _ := registry.Provider(JokeAPIName)
+
+	for i := 0; i < cfg.Resilience.Breaker.FailureThreshold; i++ {
+		p.Fetch(context.Background())
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// The half-open trial is cancelled by the caller
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Fetch(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	// The next call is still let through as the trial, and closes the circuit
+	apis.JokeAPI.SetMode(fakeapis.OK)
+	if _, err := p.Fetch(context.Background()); err != nil {
+		t.Fatalf("trial after a cancelled one = %v", err)
+	}
+	if state := p.(*ResilientProvider).Breaker().State(); state != resilience.Closed {
+		t.Errorf("state = %s, want closed", state)
+	}
+}
+
+func TestFetchJoke(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+
+	content, err := fetcher.FetchJoke(context.Background(), "funny", models.JokeKnockKnock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.JokeType != models.JokeKnockKnock || content.Provider != JokeAPIName {
+		t.Errorf("content = %+v, want a knock-knock joke from the API", content)
+	}
+
+	// The corpus serves typed jokes when the API is down
+	apis.JokeAPI.SetMode(fakeapis.Error)
+	content, err = fetcher.FetchJoke(context.Background(), "funny", models.JokeDad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.JokeType != models.JokeDad || content.Provider != corpus.ProviderName(models.KindJoke) {
+		t.Errorf("content = %+v, want a dad joke from the corpus", content)
+	}
+
+	if _, err := fetcher.FetchJoke(context.Background(), "inspiring", models.JokeDad); err == nil {
+		t.Error("expected an error for a mood without joke providers")
+	}
+}
+
+func TestFetchMoodImageProviders(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	cfg.Images.LocalDir = t.TempDir()
+	folder := filepath.Join(cfg.Images.LocalDir, "relaxing")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"lake.jpg", "notes.txt", ".hidden.png"} {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Unsplash comes first by default
+	content, err := NewMoodFetcher(newFastRegistry(t, cfg)).FetchMood(context.Background(), "relaxing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Image == nil || content.Image.Provider != UnsplashName {
+		t.Errorf("image = %+v, want one from Unsplash", content.Image)
+	}
+
+	// Without an access key the local folder is used
+	cfg.APIs.Unsplash.AccessKey = ""
+	content, err = NewMoodFetcher(newFastRegistry(t, cfg)).FetchMood(context.Background(), "relaxing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Image == nil || content.Image.Path != filepath.Join(folder, "lake.jpg") {
+		t.Errorf("image = %+v, want the only image in the local folder", content.Image)
+	}
+
+	// A mood without a folder or image providers is sent as text
+	for i := range cfg.Moods {
+		if cfg.Moods[i].Name == "thoughtful" {
+			cfg.Moods[i].ImageProviders = nil
+		}
+	}
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+	for _, mood := range []string{"funny", "thoughtful"} {
+		content, err := fetcher.FetchMood(context.Background(), mood)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.Image != nil {
+			t.Errorf("%s image = %+v, want none", mood, content.Image)
+		}
+	}
+}
+
+func TestUnknownImageProvider(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	cfg.Moods[0].ImageProviders = []string{"flickr"}
+
+	if _, err := NewDefaultRegistry(cfg); err == nil || !strings.Contains(err.Error(), "unknown image provider: flickr") {
+		t.Errorf("NewDefaultRegistry() = %v, want an unknown image provider error", err)
+	}
+}
+
+func TestTrackDownloadDispatchesByProvider(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+	location := apis.Unsplash.URL + "/photos/abc/download"
+
+	fetcher.TrackDownload(context.Background(), models.Image{Path: "images/funny/a.png", Provider: LocalImagesName})
+	if n := apis.Unsplash.RequestsTo("/photos/abc"); n != 0 {
+		t.Fatalf("local image pinged Unsplash %d times", n)
+	}
+	// Images saved before they recorded a provider came from Unsplash
+	for _, image := range []models.Image{
+		{URL: "u", Provider: UnsplashName, DownloadLocation: location},
+		{URL: "u", DownloadLocation: location},
+	} {
+		if err := fetcher.TrackDownload(context.Background(), image); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := apis.Unsplash.RequestsTo("/photos/abc/download"); n != 2 {
+		t.Errorf("download pings = %d, want 2", n)
+	}
+}
+
+func TestFetchNative(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	fetcher := NewMoodFetcher(newFastRegistry(t, cfg))
+
+	// The built-in corpus has Hindi and Tamil quotes
+	for _, lang := range []string{"hi", "ta"} {
+		content, err := fetcher.FetchNative(context.Background(), "inspiring", lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.Language != lang || content.Provider != corpus.ProviderName(models.KindQuote) {
+			t.Errorf("%s content = %+v, want a corpus quote in %s", lang, content, lang)
+		}
+	}
+
+	// Useless Facts takes a language parameter
+	content, err := fetcher.FetchNative(context.Background(), "educational", "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Language != "de" || content.Provider != UselessFactsName {
+		t.Errorf("content = %+v, want a German fact from the API", content)
+	}
+
+	if _, err := fetcher.FetchNative(context.Background(), "funny", "hi"); !errors.Is(err, ErrNoNativeSource) {
+		t.Errorf("FetchNative() = %v, want ErrNoNativeSource", err)
+	}
+}
+
+func TestRegistryHealth(t *testing.T) {
+	cfg, apis := newTestConfig(t)
+	cfg.Resilience.Breaker.FailureThreshold = 1
+	apis.ZenQuotes.SetMode(fakeapis.Error)
+	registry := newFastRegistry(t, cfg)
+
+	if _, err := NewMoodFetcher(registry).FetchMood(context.Background(), "inspiring"); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]ProviderHealth)
+	for _, h := range registry.Health() {
+		byName[h.Name] = h
+	}
+	zen := byName[ZenQuotesName]
+	if zen.Health == nil || zen.Health.Failures != int64(cfg.Resilience.Retry.MaxAttempts) || zen.Health.LastError == "" {
+		t.Errorf("zenquotes health = %+v, want every failed attempt and the last error", zen.Health)
+	}
+	if zen.Circuit != "open" {
+		t.Errorf("zenquotes circuit = %q, want open", zen.Circuit)
+	}
+	if dummy := byName[DummyJSONName]; dummy.Health == nil || dummy.Health.Successes != 1 || dummy.Circuit != "closed" {
+		t.Errorf("dummyjson health = %+v", dummy)
+	}
+	if c := byName[corpus.ProviderName(models.KindQuote)]; c.Health != nil || c.Circuit != "" || !c.Configured {
+		t.Errorf("corpus health = %+v, want an untracked provider", c)
+	}
+	if u := byName[UnsplashName]; u.Kind != "image" || !u.Configured || u.Health == nil || u.Health.Successes != 1 {
+		t.Errorf("unsplash health = %+v", u)
+	}
+	if local := byName[LocalImagesName]; local.Configured {
+		t.Errorf("local images = %+v, want not configured without the folder", local)
+	}
+}
@@ -0,0 +1,340 @@
+package fetchers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/corpus"
+	"github.com/you/moodbot/health"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/resilience"
+)
+
+// ContentProvider is a source of content that moods can be bound to
+type ContentProvider interface {
+	// Name returns the unique name the provider is registered under
+	Name() string
+	// Kind returns the kind of content the provider returns
+	Kind() models.ContentKind
+	// Fetch fetches a single piece of content
+	Fetch(ctx context.Context) (models.Content, error)
+}
+
+// JokeTypeProvider is a joke provider that can serve jokes of a given type
+type JokeTypeProvider interface {
+	ContentProvider
+	// FetchJokeType fetches a single joke of jokeType, e.g. "programming"
+	FetchJokeType(ctx context.Context, jokeType string) (models.Content, error)
+}
+
+// LanguageProvider is a content provider that declares the languages it serves
+// natively. Providers that don't implement it serve English only.
+type LanguageProvider interface {
+	ContentProvider
+	// Languages returns the codes of the languages the provider serves, e.g. "en", "hi"
+	Languages() []string
+	// FetchLanguage fetches a single piece of content written in lang
+	FetchLanguage(ctx context.Context, lang string) (models.Content, error)
+}
+
+// OfflineProvider is a content provider that never calls the network, such as
+// the embedded corpus. Fallback chains always try it, even once the caller's
+// deadline has passed.
+type OfflineProvider interface {
+	ContentProvider
+	// Offline reports whether the provider serves content without network calls
+	Offline() bool
+}
+
+// isOffline reports whether p serves content without network calls
+func isOffline(p ContentProvider) bool {
+	op, ok := p.(OfflineProvider)
+	return ok && op.Offline()
+}
+
+// SupportsLanguage reports whether p serves content natively in lang
+func SupportsLanguage(p ContentProvider, lang string) bool {
+	languages := []string{"en"}
+	if lp, ok := p.(LanguageProvider); ok {
+		languages = lp.Languages()
+	}
+	for _, l := range languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageProvider is a source of images that moods can list
+type ImageProvider interface {
+	// Name returns the unique name the provider is registered under
+	Name() string
+	// ImageForMood fetches a single image for a mood
+	ImageForMood(ctx context.Context, category models.ContentCategory) (models.Image, error)
+}
+
+// DownloadTracker is an image provider that must be told whenever one of its images is shown
+type DownloadTracker interface {
+	TrackDownload(ctx context.Context, image models.Image) error
+}
+
+// Registry holds the registered content and image providers and each mood's provider chains
+type Registry struct {
+	providers      map[string]ContentProvider
+	imageProviders map[string]ImageProvider
+	moods          map[string]models.ContentCategory
+	moodOrder      []string
+	mutex          sync.RWMutex
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		providers:      make(map[string]ContentProvider),
+		imageProviders: make(map[string]ImageProvider),
+		moods:          make(map[string]models.ContentCategory),
+	}
+}
+
+// NewDefaultRegistry creates a registry with the built-in providers, each
+// wrapped with retries and a circuit breaker, the offline corpus, the image
+// providers and the configured moods
+func NewDefaultRegistry(cfg config.Config) (*Registry, error) {
+	r := NewRegistry()
+	for _, p := range []ContentProvider{
+		NewZenQuotesProvider(cfg.APIs.ZenQuotes),
+		NewDummyJSONProvider(cfg.APIs.DummyJSON),
+		NewJokeAPIProvider(cfg.APIs.JokeAPI),
+		NewUselessFactsProvider(cfg.APIs.UselessFacts),
+	} {
+		r.Register(NewResilientProvider(p, cfg.Resilience))
+	}
+
+	// The corpus is local, so it needs no retries
+	c, err := corpus.Load(corpus.Dir(cfg.DataDir))
+	if err != nil {
+		return nil, fmt.Errorf("error loading corpus: %v", err)
+	}
+	for _, kind := range []models.ContentKind{models.KindQuote, models.KindJoke, models.KindFact} {
+		r.Register(corpus.NewProvider(c, kind))
+	}
+
+	r.RegisterImageProvider(NewUnsplashClient(cfg.APIs.Unsplash))
+	r.RegisterImageProvider(NewLocalImageProvider(cfg.Images.LocalDir))
+
+	for _, mood := range cfg.Moods {
+		providers := mood.Providers
+		if len(providers) > 0 {
+			if p, exists := r.Provider(providers[0]); exists {
+				providers = withCorpus(providers, corpus.ProviderName(p.Kind()), cfg.Corpus.Primary)
+			}
+		}
+		r.AddMood(models.ContentCategory{
+			Name:           mood.Name,
+			Label:          mood.Label,
+			Emoji:          mood.Emoji,
+			ImageQuery:     mood.ImageQuery,
+			Providers:      providers,
+			ImageProviders: mood.ImageProviders,
+		})
+	}
+
+	// Catch typos in provider names at startup rather than on the first tap
+	for _, mood := range cfg.Moods {
+		if _, err := r.ProvidersForMood(mood.Name); err != nil {
+			return nil, err
+		}
+		if _, err := r.ImageProvidersForMood(mood.Name); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// withCorpus puts the corpus provider at the end of a chain, or at the front when primary
+func withCorpus(chain []string, corpusName string, primary bool) []string {
+	result := make([]string, 0, len(chain)+1)
+	if primary {
+		result = append(result, corpusName)
+	}
+	for _, name := range chain {
+		if name != corpusName {
+			result = append(result, name)
+		}
+	}
+	if !primary {
+		result = append(result, corpusName)
+	}
+	return result
+}
+
+// Register adds a provider, replacing any provider with the same name
+func (r *Registry) Register(p ContentProvider) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.providers[p.Name()] = p
+}
+
+// Provider returns the provider registered under name
+func (r *Registry) Provider(name string) (ContentProvider, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.providers[name]
+	return p, exists
+}
+
+// RegisterImageProvider adds an image provider, replacing any image provider with the same name
+func (r *Registry) RegisterImageProvider(p ImageProvider) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.imageProviders[p.Name()] = p
+}
+
+// ImageProvider returns the image provider registered under name
+func (r *Registry) ImageProvider(name string) (ImageProvider, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.imageProviders[name]
+	return p, exists
+}
+
+// AddMood binds a mood to its provider chain, replacing any existing binding
+func (r *Registry) AddMood(category models.ContentCategory) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.moods[category.Name]; !exists {
+		r.moodOrder = append(r.moodOrder, category.Name)
+	}
+	r.moods[category.Name] = category
+}
+
+// Mood returns the category registered for a mood
+func (r *Registry) Mood(name string) (models.ContentCategory, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	category, exists := r.moods[name]
+	return category, exists
+}
+
+// Moods returns all moods in the order they were added
+func (r *Registry) Moods() []models.ContentCategory {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make([]models.ContentCategory, 0, len(r.moodOrder))
+	for _, name := range r.moodOrder {
+		result = append(result, r.moods[name])
+	}
+	return result
+}
+
+// KindForMood returns the kind of content a mood serves, taken from the first provider in its chain
+func (r *Registry) KindForMood(mood string) (models.ContentKind, error) {
+	chain, err := r.ProvidersForMood(mood)
+	if err != nil {
+		return "", err
+	}
+	return chain[0].Kind(), nil
+}
+
+// ProvidersForMood returns a mood's provider chain in fallback order
+func (r *Registry) ProvidersForMood(mood string) ([]ContentProvider, error) {
+	category, exists := r.Mood(mood)
+	if !exists {
+		return nil, fmt.Errorf("unknown mood: %s", mood)
+	}
+	if len(category.Providers) == 0 {
+		return nil, fmt.Errorf("mood %s has no providers", mood)
+	}
+
+	chain := make([]ContentProvider, 0, len(category.Providers))
+	for _, name := range category.Providers {
+		p, exists := r.Provider(name)
+		if !exists {
+			return nil, fmt.Errorf("mood %s uses unknown provider: %s", mood, name)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+// ImageProvidersForMood returns a mood's image providers in priority order; it is empty for text-only moods
+func (r *Registry) ImageProvidersForMood(mood string) ([]ImageProvider, error) {
+	category, exists := r.Mood(mood)
+	if !exists {
+		return nil, fmt.Errorf("unknown mood: %s", mood)
+	}
+
+	chain := make([]ImageProvider, 0, len(category.ImageProviders))
+	for _, name := range category.ImageProviders {
+		p, exists := r.ImageProvider(name)
+		if !exists {
+			return nil, fmt.Errorf("mood %s uses unknown image provider: %s", mood, name)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+// ProviderHealth is the health of one registered content or image provider
+type ProviderHealth struct {
+	Name       string
+	Kind       string           // content kind, or "image"
+	Configured bool             // false when the provider is missing settings it needs, such as an API key
+	Health     *health.Snapshot // nil for providers that don't track calls, such as the corpus
+	Circuit    string           // circuit breaker state, empty for providers without one
+}
+
+// Health returns the health of every registered provider, content providers first, each sorted by name
+func (r *Registry) Health() []ProviderHealth {
+	r.mutex.RLock()
+	var content []ContentProvider
+	for _, p := range r.providers {
+		content = append(content, p)
+	}
+	var images []ImageProvider
+	for _, p := range r.imageProviders {
+		images = append(images, p)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(content, func(i, j int) bool { return content[i].Name() < content[j].Name() })
+	sort.Slice(images, func(i, j int) bool { return images[i].Name() < images[j].Name() })
+
+	result := make([]ProviderHealth, 0, len(content)+len(images))
+	for _, p := range content {
+		result = append(result, providerHealth(p, p.Name(), string(p.Kind())))
+	}
+	for _, p := range images {
+		result = append(result, providerHealth(p, p.Name(), "image"))
+	}
+	return result
+}
+
+// providerHealth collects whatever health information p offers
+func providerHealth(p interface{}, name, kind string) ProviderHealth {
+	h := ProviderHealth{Name: name, Kind: kind, Configured: true}
+	if c, ok := p.(interface{ Configured() bool }); ok {
+		h.Configured = c.Configured()
+	}
+	if t, ok := p.(interface{ Health() health.Snapshot }); ok {
+		snapshot := t.Health()
+		h.Health = &snapshot
+	}
+	if b, ok := p.(interface {
+		Breaker() *resilience.CircuitBreaker
+	}); ok {
+		h.Circuit = b.Breaker().State().String()
+	}
+	return h
+}
@@ -0,0 +1,114 @@
+package fetchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/health"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/resilience"
+)
+
+// ResilientProvider wraps a provider with retries and a circuit breaker, and tracks its health
+type ResilientProvider struct {
+	provider ContentProvider
+	policy   resilience.RetryPolicy
+	breaker  *resilience.CircuitBreaker
+	health   *health.Tracker
+}
+
+// NewResilientProvider wraps provider with the retry and breaker settings in cfg
+func NewResilientProvider(provider ContentProvider, cfg config.ResilienceConfig) *ResilientProvider {
+	return &ResilientProvider{
+		provider: provider,
+		policy: resilience.RetryPolicy{
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			BaseDelay:   cfg.Retry.BaseDelay.Duration,
+			MaxDelay:    cfg.Retry.MaxDelay.Duration,
+		},
+		breaker: resilience.NewCircuitBreaker(cfg.Breaker.FailureThreshold, cfg.Breaker.Cooldown.Duration),
+		health:  health.NewTracker(),
+	}
+}
+
+// Name returns the wrapped provider's name
+func (p *ResilientProvider) Name() string { return p.provider.Name() }
+
+// Kind returns the wrapped provider's kind
+func (p *ResilientProvider) Kind() models.ContentKind { return p.provider.Kind() }
+
+// Breaker returns the provider's circuit breaker
+func (p *ResilientProvider) Breaker() *resilience.CircuitBreaker { return p.breaker }
+
+// Health returns the outcome and latency of every attempt made against the upstream
+func (p *ResilientProvider) Health() health.Snapshot { return p.health.Snapshot() }
+
+// Fetch fetches from the wrapped provider, retrying transient failures.
+// While the circuit is open it fails fast without calling the upstream.
+func (p *ResilientProvider) Fetch(ctx context.Context) (models.Content, error) {
+	return p.do(ctx, p.provider.Fetch)
+}
+
+// FetchJokeType fetches a joke of a given type like Fetch, if the wrapped provider supports joke types
+func (p *ResilientProvider) FetchJokeType(ctx context.Context, jokeType string) (models.Content, error) {
+	typed, ok := p.provider.(JokeTypeProvider)
+	if !ok {
+		return models.Content{}, fmt.Errorf("%s does not support joke types", p.Name())
+	}
+	return p.do(ctx, func(ctx context.Context) (models.Content, error) {
+		return typed.FetchJokeType(ctx, jokeType)
+	})
+}
+
+// Languages returns the languages the wrapped provider serves natively
+func (p *ResilientProvider) Languages() []string {
+	if lp, ok := p.provider.(LanguageProvider); ok {
+		return lp.Languages()
+	}
+	return []string{"en"}
+}
+
+// FetchLanguage fetches content in lang like Fetch, if the wrapped provider serves other languages
+func (p *ResilientProvider) FetchLanguage(ctx context.Context, lang string) (models.Content, error) {
+	lp, ok := p.provider.(LanguageProvider)
+	if !ok {
+		return models.Content{}, fmt.Errorf("%s does not serve other languages", p.Name())
+	}
+	return p.do(ctx, func(ctx context.Context) (models.Content, error) {
+		return lp.FetchLanguage(ctx, lang)
+	})
+}
+
+// do runs fetch with retries behind the circuit breaker
+func (p *ResilientProvider) do(ctx context.Context, fetch func(ctx context.Context) (models.Content, error)) (models.Content, error) {
+	if err := p.breaker.Allow(); err != nil {
+		return models.Content{}, fmt.Errorf("%s: %w", p.Name(), err)
+	}
+
+	var content models.Content
+	err := resilience.Retry(ctx, p.policy, func(ctx context.Context) error {
+		start := time.Now()
+		var err error
+		content, err = fetch(ctx)
+		if !errors.Is(err, context.Canceled) {
+			p.health.Record(time.Since(start), err)
+		}
+		return err
+	})
+
+	switch {
+	case err == nil:
+		p.breaker.Success()
+	case errors.Is(err, context.Canceled), err == ctx.Err(), resilience.IsPermanent(err):
+		// The caller gave up, its deadline passed while waiting to retry, or the
+		// upstream answered but had nothing for this request (e.g. a 404); none
+		// of these says the upstream is down
+		p.breaker.Release()
+	default:
+		p.breaker.Failure()
+	}
+	return content, err
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/translation"
+)
+
+// RenderContent turns structured content into Telegram message text
+func RenderContent(content models.Content) string {
+	switch content.Kind {
+	case models.KindQuote:
+		if content.Author == "" {
+			return fmt.Sprintf("\"%s\"", content.Body)
+		}
+		return fmt.Sprintf("\"%s\" — %s", content.Body, content.Author)
+	case models.KindJoke:
+		return fmt.Sprintf("%s\n\n%s", content.Setup, content.Punchline)
+	default:
+		return content.Body
+	}
+}
+
+// renderForUser translates content into the user's language and renders it as message text after header
+func (mh *MessageHandler) renderForUser(ctx context.Context, header string, content models.Content, lang translation.Language) string {
+	text := header + RenderContent(mh.translateContent(ctx, content, lang))
+	return mh.languageManager.Languages().FormatText(text, lang)
+}
+
+// translateFavorite translates a saved favorite like translateContent. Favorites saved
+// before their language was recorded are assumed to be English.
+func (mh *MessageHandler) translateFavorite(ctx context.Context, fav models.Favorite, lang translation.Language) models.Favorite {
+	source := sourceLanguage(fav.Language)
+	if source == lang || !mh.translator.IsTranslationSupported() {
+		return fav
+	}
+
+	translated := fav
+	if fav.Content != "" && fav.Type != string(models.KindJoke) { // jokes show setup and punchline
+		translated.Content, _ = mh.translator.TranslateFrom(ctx, fav.Content, source, lang)
+	}
+	if fav.Setup != "" {
+		translated.Setup, _ = mh.translator.TranslateFrom(ctx, fav.Setup, source, lang)
+	}
+	if fav.Punchline != "" {
+		translated.Punchline, _ = mh.translator.TranslateFrom(ctx, fav.Punchline, source, lang)
+	}
+	return translated
+}
+
+// translateContent translates the user-visible fields of content from the language it is
+// written in, keeping the original on failure. Content already written in the user's
+// language, or with no backend able to translate it, is left alone.
+func (mh *MessageHandler) translateContent(ctx context.Context, content models.Content, lang translation.Language) models.Content {
+	source := sourceLanguage(content.Language)
+	if source == lang || !mh.translator.IsTranslationSupported() {
+		return content
+	}
+
+	translated := content
+	if content.Body != "" {
+		translated.Body, _ = mh.translator.TranslateFrom(ctx, content.Body, source, lang)
+	}
+	if content.Setup != "" {
+		translated.Setup, _ = mh.translator.TranslateFrom(ctx, content.Setup, source, lang)
+	}
+	if content.Punchline != "" {
+		translated.Punchline, _ = mh.translator.TranslateFrom(ctx, content.Punchline, source, lang)
+	}
+	return translated
+}
+
+// sourceLanguage returns the language content says it is written in, English if it doesn't say
+func sourceLanguage(lang string) translation.Language {
+	if lang == "" {
+		return translation.English
+	}
+	return translation.Language(lang)
+}
@@ -2,45 +2,164 @@ package handlers
 
 import (
 	"context"
-	"time"
+	"fmt"
+	"os"
+	"path/filepath"
 
-	"github.com/you/moodbot/fetchers"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/models"
 )
 
-// sendContentWithImage is a helper method to send content with optional image
-func (mh *MessageHandler) sendContentWithImage(chatID int64, text, imageQuery, contentType string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
-	defer cancel()
+// maxAlertLength is the longest text Telegram shows in a callback alert
+const maxAlertLength = 200
+
+// sendContentWithImage is a helper method to send content with its image, if it has one,
+// translated into the user's language after header (e.g. the surprise banner). The untranslated
+// content is recorded as a delivery so votes and favorites can refer to it, and in the user's
+// history so it isn't repeated.
+func (mh *MessageHandler) sendContentWithImage(ctx context.Context, chatID, userID int64, header, contentType string, content models.Content) {
+	deliveryID := mh.deliveryStore.Record(chatID, contentType, content)
+	mh.history.Record(userID, contentType, deliveryID, content)
 
-	var imageURL string
-	if imageQuery != "" {
-		imageURL, _ = fetchers.FetchUnsplashImage(ctx, imageQuery)
+	// Knock-knock jokes are revealed one line at a time, each line translated as it's sent
+	if dialogue, ok := knockKnockDialogue(content); ok {
+		delivery, _ := mh.deliveryStore.Get(deliveryID)
+		mh.sendKnockKnockStep(chatID, delivery, dialogue, 0, header)
+		return
 	}
 
-	messageID := int(time.Now().Unix())
-	
-	if imageURL != "" {
-		// Send photo with caption
-		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(imageURL))
-		photo.Caption = text
+	text := mh.renderForUser(ctx, header, content, mh.languageManager.GetUserLanguage(chatID))
+
+	if content.Image != nil {
+		keyboard := mh.voteManager.CreateVotingKeyboard(contentType, deliveryID)
+		if content.Image.AltText != "" {
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mh.localize(chatID, "photo.describe"), "alt_"+deliveryID),
+			))
+		}
+		if mh.sendPhoto(chatID, content.Image, text, keyboard) {
+			return
+		}
+		// Fallback to text message if photo fails
+	}
+	mh.sendTextMessage(chatID, text, contentType, deliveryID)
+}
+
+// sendPhoto sends an image with a caption crediting its photographer and reports whether it was sent.
+// Images Telegram has seen before are sent by file ID; successful sends are reported to the image
+// source, which Unsplash requires.
+func (mh *MessageHandler) sendPhoto(chatID int64, image *models.Image, caption string, keyboard tgbotapi.InlineKeyboardMarkup) bool {
+	send := func(file tgbotapi.RequestFileData) (tgbotapi.Message, error) {
+		photo := tgbotapi.NewPhoto(chatID, file)
+		photo.Caption = caption + mh.imageCredit(chatID, image)
 		photo.ParseMode = "Markdown"
-		photo.ReplyMarkup = mh.voteManager.CreateVotingKeyboard(contentType, messageID)
-		_, err := mh.bot.Send(photo)
+		photo.ReplyMarkup = keyboard
+		return mh.bot.Send(photo)
+	}
+
+	key := photoKey(image)
+	sent := false
+	if fileID, exists := mh.fileIDs.Get(key); exists {
+		if _, err := send(tgbotapi.FileID(fileID)); err == nil {
+			sent = true
+		} else {
+			// Telegram may have dropped the file; send the original instead
+			mh.fileIDs.Delete(key)
+		}
+	}
+	if !sent {
+		file, err := photoFile(image)
+		if err != nil {
+			fmt.Printf("Warning: Could not read image: %v\n", err)
+			return false
+		}
+		msg, err := send(file)
 		if err != nil {
-			// Fallback to text message if photo fails
-			mh.sendTextMessage(chatID, text, contentType, messageID)
+			return false
 		}
-	} else {
-		// Send text message
-		mh.sendTextMessage(chatID, text, contentType, messageID)
+		if len(msg.Photo) > 0 {
+			// Every size shares the upload; the last is the largest
+			mh.fileIDs.Set(key, msg.Photo[len(msg.Photo)-1].FileID)
+		}
+	}
+
+	if mh.images != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.UITimeout.Duration)
+		defer cancel()
+		mh.images.TrackDownload(ctx, *image)
 	}
+	return true
+}
+
+// photoKey returns the key an image's file ID is remembered under: its local path or URL
+func photoKey(image *models.Image) string {
+	if image.Path != "" {
+		return image.Path
+	}
+	return image.URL
+}
+
+// photoFile returns the file to send for an image: local files are uploaded, remote ones sent by URL
+func photoFile(image *models.Image) (tgbotapi.RequestFileData, error) {
+	if image.Path == "" {
+		return tgbotapi.FileURL(image.URL), nil
+	}
+	data, err := os.ReadFile(image.Path)
+	if err != nil {
+		return nil, err
+	}
+	return tgbotapi.FileBytes{Name: filepath.Base(image.Path), Bytes: data}, nil
+}
+
+// imageCredit returns the caption line attributing an image, e.g. "📷 Photo by X on Unsplash"
+func (mh *MessageHandler) imageCredit(chatID int64, image *models.Image) string {
+	credit := image.Credit
+	if credit == nil || credit.Name == "" {
+		return ""
+	}
+	return "\n\n" + mh.localize(chatID, "photo.credit",
+		"name", markdownLink(credit.Name, credit.ProfileURL), "source", markdownLink(credit.Source, credit.SourceURL))
+}
+
+// markdownLink formats a Markdown link, or escaped plain text when there is no URL
+func markdownLink(text, url string) string {
+	text = tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text)
+	if url == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// HandleAltText handles alt_{deliveryID} callbacks and returns the photo's description for an alert
+func (mh *MessageHandler) HandleAltText(data string, chatID int64) string {
+	d, exists := mh.deliveryStore.Get(data[len("alt_"):])
+	if !exists || d.Content.Image == nil || d.Content.Image.AltText == "" {
+		return mh.localize(chatID, "photo.no_description")
+	}
+
+	userLang := mh.languageManager.GetUserLanguage(chatID)
+	ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.UITimeout.Duration)
+	defer cancel()
+
+	text, _ := mh.translator.TranslateText(ctx, d.Content.Image.AltText, userLang)
+	text = "🖼️ " + text
+	if runes := []rune(text); len(runes) > maxAlertLength {
+		text = string(runes[:maxAlertLength-1]) + "…"
+	}
+	return text
 }
 
 // sendTextMessage is a helper method to send text-only messages
-func (mh *MessageHandler) sendTextMessage(chatID int64, text, contentType string, messageID int) {
+func (mh *MessageHandler) sendTextMessage(chatID int64, text, contentType string, deliveryID string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = mh.voteManager.CreateVotingKeyboard(contentType, deliveryID)
+	mh.bot.Send(msg)
+}
+
+// sendNotice sends a plain message without voting buttons, e.g. when content couldn't be fetched
+func (mh *MessageHandler) sendNotice(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "Markdown"
-	msg.ReplyMarkup = mh.voteManager.CreateVotingKeyboard(contentType, messageID)
 	mh.bot.Send(msg)
-}
\ No newline at end of file
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/telegramtest"
+)
+
+// imageSource serves a fixed quote with an image
+type imageSource struct {
+	image models.Image
+}
+
+func (s *imageSource) FetchMood(ctx context.Context, mood string) (models.Content, error) {
+	image := s.image
+	return models.Content{Kind: models.KindQuote, Body: "Look closer.", Author: "Anon", Image: &image}, nil
+}
+
+func (s *imageSource) FetchJoke(ctx context.Context, mood, jokeType string) (models.Content, error) {
+	return s.FetchMood(ctx, mood)
+}
+
+func (s *imageSource) FetchNative(ctx context.Context, mood, lang string) (models.Content, error) {
+	return s.FetchMood(ctx, mood)
+}
+
+func (s *imageSource) AddImage(ctx context.Context, mood string, content models.Content) models.Content {
+	return content
+}
+
+// recordingTracker records the images it is told about
+type recordingTracker struct {
+	tracked []models.Image
+}
+
+func (t *recordingTracker) TrackDownload(ctx context.Context, image models.Image) error {
+	t.tracked = append(t.tracked, image)
+	return nil
+}
+
+var testImage = models.Image{
+	URL:     "https://images.example.com/lake.png",
+	AltText: "a calm lake at dawn",
+	Credit: &models.ImageCredit{
+		Name:       "Jane_Doe",
+		ProfileURL: "https://unsplash.com/@jane?utm_source=moodbot&utm_medium=referral",
+		Source:     "Unsplash",
+		SourceURL:  "https://unsplash.com/?utm_source=moodbot&utm_medium=referral",
+	},
+	DownloadLocation: "https://api.unsplash.com/photos/abc/download",
+}
+
+func TestSendPhotoWithAttribution(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	tracker := &recordingTracker{}
+	mh.content = &imageSource{image: testImage}
+	mh.images = tracker
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+	photos := recorder.Photos()
+	if len(photos) != 1 {
+		t.Fatalf("got %d photos, want 1", len(photos))
+	}
+	wantCredit := "\n\n📷 Photo by [Jane\\_Doe](https://unsplash.com/@jane?utm_source=moodbot&utm_medium=referral) on [Unsplash](https://unsplash.com/?utm_source=moodbot&utm_medium=referral)"
+	if !strings.HasSuffix(photos[0].Caption, wantCredit) {
+		t.Errorf("caption = %q, want it to end with %q", photos[0].Caption, wantCredit)
+	}
+	if len(tracker.tracked) != 1 || tracker.tracked[0].DownloadLocation != testImage.DownloadLocation {
+		t.Errorf("tracked = %+v, want one download ping", tracker.tracked)
+	}
+
+	buttons := telegramtest.Buttons(photos[0].ReplyMarkup)
+	if len(buttons) != 2 || !strings.HasPrefix(buttons[1][0], "🖼️ Describe photo=alt_") {
+		t.Fatalf("buttons = %v, want a describe button under the voting row", buttons)
+	}
+	data := buttons[1][0][strings.Index(buttons[1][0], "=")+1:]
+	if answer := mh.HandleAltText(data, testChatID); answer != "🖼️ a calm lake at dawn" {
+		t.Errorf("alt text answer = %q", answer)
+	}
+}
+
+func TestSendPhotoFailureIsNotTracked(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	tracker := &recordingTracker{}
+	mh.content = &imageSource{image: testImage}
+	mh.images = tracker
+	recorder.FailPhotos = true
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+	if len(tracker.tracked) != 0 {
+		t.Errorf("tracked %d downloads for a photo that wasn't sent", len(tracker.tracked))
+	}
+	if messages := recorder.Messages(); len(messages) != 2 || strings.Contains(messages[0].Text, "Photo by") {
+		t.Errorf("messages = %+v, want the text fallback without a credit", messages)
+	}
+}
+
+func TestHandleAltTextMissing(t *testing.T) {
+	mh, _ := newTestHandler(t)
+
+	if answer := mh.HandleAltText("alt_missing", testChatID); answer != "Sorry, there's no description for this photo." {
+		t.Errorf("answer = %q", answer)
+	}
+}
+
+func TestSendLocalImageUploadsFile(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	path := filepath.Join(t.TempDir(), "calm.png")
+	if err := os.WriteFile(path, []byte("not really a png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mh.content = &imageSource{image: models.Image{Path: path, Provider: "local"}}
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+	photos := recorder.Photos()
+	if len(photos) != 1 {
+		t.Fatalf("got %d photos, want 1", len(photos))
+	}
+	file, ok := photos[0].File.(tgbotapi.FileBytes)
+	if !ok || file.Name != "calm.png" || string(file.Bytes) != "not really a png" {
+		t.Errorf("file = %#v, want the local file's bytes", photos[0].File)
+	}
+}
+
+func TestSendPhotoReusesFileID(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.content = &imageSource{image: testImage}
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+	photos := recorder.Photos()
+	if len(photos) != 2 {
+		t.Fatalf("got %d photos, want 2", len(photos))
+	}
+	if _, ok := photos[0].File.(tgbotapi.FileURL); !ok {
+		t.Errorf("first send = %#v, want the URL", photos[0].File)
+	}
+	if fileID, ok := photos[1].File.(tgbotapi.FileID); !ok || fileID != "photo-1" {
+		t.Errorf("second send = %#v, want the file ID from the first", photos[1].File)
+	}
+}
+
+func TestSendPhotoRejectedFileIDResends(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.content = &imageSource{image: testImage}
+	mh.fileIDs.Set(testImage.URL, "expired")
+	recorder.RejectFileIDs = true
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+	photos := recorder.Photos()
+	if len(photos) != 2 {
+		t.Fatalf("got %d photos, want a file ID attempt then the URL", len(photos))
+	}
+	if _, ok := photos[1].File.(tgbotapi.FileURL); !ok {
+		t.Errorf("retry = %#v, want the URL", photos[1].File)
+	}
+	if fileID, _ := mh.fileIDs.Get(testImage.URL); fileID == "expired" {
+		t.Error("the rejected file ID was kept")
+	}
+}
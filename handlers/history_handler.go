@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/fetchers"
+	"github.com/you/moodbot/i18n"
+	"github.com/you/moodbot/models"
+)
+
+// fetchUnseen calls fetch, calling it again up to cfg.Repicks times while the
+// user has seen the result recently. A repeat beats sending nothing, so the
+// last item is returned if every pick was seen. Picks are fetched without an
+// image, and the mood's image is fetched once for the item returned.
+func (mh *MessageHandler) fetchUnseen(ctx context.Context, userID int64, mood string, fetch func(ctx context.Context) (models.Content, error)) (models.Content, error) {
+	// Repicks are thrown away, so only the content is fetched until one is chosen
+	textCtx := fetchers.WithoutImage(ctx)
+	content, err := fetch(textCtx)
+	if err != nil {
+		return content, err
+	}
+
+	for i := 0; i < mh.cfg.Repicks && mh.history.SeenRecently(userID, content); i++ {
+		next, err := fetch(textCtx)
+		if err != nil {
+			break
+		}
+		content = next
+	}
+	// Knock-knock jokes are revealed line by line as text, so they don't get an image
+	if _, ok := knockKnockDialogue(content); ok {
+		return content, nil
+	}
+	return mh.content.AddImage(ctx, mood, content), nil
+}
+
+// SendHistory lists the items most recently delivered to the user
+func (mh *MessageHandler) SendHistory(chatID, userID int64) {
+	userLang := string(mh.languageManager.GetUserLanguage(chatID))
+
+	entries := mh.history.Recent(userID, mh.cfg.HistorySize)
+	if len(entries) == 0 {
+		mh.sendNotice(chatID, mh.localize(chatID, "history.empty"))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(mh.localize(chatID, "history.header"))
+	b.WriteString("\n")
+	now := time.Now()
+	for i, entry := range entries {
+		// Surprise deliveries aren't a registry mood but the catalog has a label for them
+		category, exists := mh.registry.Mood(entry.Mood)
+		if !exists {
+			category = models.ContentCategory{Name: entry.Mood, Label: entry.Mood}
+		}
+		mood := mh.moodLabel(chatID, category)
+		if category.Emoji != "" {
+			mood = category.Emoji + " " + mood
+		}
+		fmt.Fprintf(&b, "\n%d. %s · %s\n%s\n", i+1, mood, timeAgo(mh.catalog, userLang, now.Sub(time.Unix(entry.SeenAt, 0))), entry.Summary)
+	}
+
+	// Summaries are third-party text, so no Markdown parsing
+	mh.bot.Send(tgbotapi.NewMessage(chatID, b.String()))
+}
+
+// timeAgo formats a duration as a short relative time in lang, such as "5m ago"
+func timeAgo(catalog *i18n.Catalog, lang string, d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return catalog.T(lang, "time.just_now")
+	case d < time.Hour:
+		return catalog.T(lang, "time.minutes_ago", "count", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return catalog.T(lang, "time.hours_ago", "count", int(d.Hours()))
+	default:
+		return catalog.T(lang, "time.days_ago", "count", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/you/moodbot/models"
+)
+
+// sequenceProvider returns the given quotes in order, repeating the last one
+type sequenceProvider struct {
+	quotes []string
+	calls  int
+	mutex  sync.Mutex
+}
+
+func (p *sequenceProvider) Name() string             { return "sequence" }
+func (p *sequenceProvider) Kind() models.ContentKind { return models.KindQuote }
+func (p *sequenceProvider) Fetch(ctx context.Context) (models.Content, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	i := p.calls
+	if i >= len(p.quotes) {
+		i = len(p.quotes) - 1
+	}
+	p.calls++
+	return models.Content{Kind: models.KindQuote, Body: p.quotes[i], Author: "Anon", Provider: "sequence"}, nil
+}
+
+func TestHandleMoodSelectionSkipsSeenContent(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	provider := &sequenceProvider{quotes: []string{"First", "First", "Second"}}
+	mh.registry.Register(provider)
+	mh.registry.AddMood(models.ContentCategory{Name: "calm", Label: "Calm", Providers: []string{"sequence"}})
+
+	mh.HandleMoodSelection("calm", testChatID, testChatID)
+	mh.HandleMoodSelection("calm", testChatID, testChatID)
+
+	// Each selection sends the content followed by the mood keyboard
+	messages := recorder.Messages()
+	if len(messages) != 4 {
+		t.Fatalf("got %d messages, want 4", len(messages))
+	}
+	if !strings.Contains(messages[0].Text, "First") || !strings.Contains(messages[2].Text, "Second") {
+		t.Errorf("sent %q then %q, want the repeat replaced", messages[0].Text, messages[2].Text)
+	}
+	if provider.calls != 3 {
+		t.Errorf("provider called %d times, want 3", provider.calls)
+	}
+}
+
+func TestHandleMoodSelectionRepeatsWhenNothingNew(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+	messages := recorder.Messages()
+	if len(messages) != 4 || !strings.Contains(messages[2].Text, "Stay hungry") {
+		t.Fatalf("messages = %+v, want the only quote sent again", messages)
+	}
+}
+
+func TestSendHistory(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	mh.SendHistory(testChatID, testChatID)
+	if text := recorder.Messages()[0].Text; !strings.Contains(text, "Nothing here yet") {
+		t.Errorf("empty history text = %q", text)
+	}
+	recorder.Reset()
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	mh.HandleMoodSelection("funny", testChatID, testChatID)
+	recorder.Reset()
+
+	mh.SendHistory(testChatID, testChatID)
+	text := recorder.Messages()[0].Text
+	funny, inspiring := strings.Index(text, "1. 😂 Funny"), strings.Index(text, "2. 💡 Inspiring")
+	if funny < 0 || inspiring < funny {
+		t.Errorf("history = %q, want funny then inspiring", text)
+	}
+	if !strings.Contains(text, "Why did the gopher cross the road?") || !strings.Contains(text, "Stay hungry, stay foolish.") {
+		t.Errorf("history = %q, want content summaries", text)
+	}
+}
+
+func TestSendHistoryLabelsSurprise(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.languageManager.SetUserLanguage(testChatID, hindi)
+
+	mh.HandleSurprise(testChatID, testChatID)
+	recorder.Reset()
+
+	mh.SendHistory(testChatID, testChatID)
+	if text := recorder.Messages()[0].Text; !strings.Contains(text, "1. सरप्राइज़ · ") {
+		t.Errorf("history = %q, want the surprise label in Hindi", text)
+	}
+}
+
+// countingImages counts the images it is asked for
+type countingImages struct {
+	calls int
+}
+
+func (p *countingImages) Name() string { return "counting" }
+func (p *countingImages) ImageForMood(ctx context.Context, category models.ContentCategory) (models.Image, error) {
+	p.calls++
+	return models.Image{URL: "https://images.example.com/calm.png", Provider: "counting"}, nil
+}
+
+func TestRepicksFetchOneImage(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	provider := &sequenceProvider{quotes: []string{"First", "First", "First", "Second"}}
+	images := &countingImages{}
+	mh.registry.Register(provider)
+	mh.registry.RegisterImageProvider(images)
+	mh.registry.AddMood(models.ContentCategory{Name: "calm", Label: "Calm", Providers: []string{"sequence"}, ImageProviders: []string{"counting"}})
+
+	mh.HandleMoodSelection("calm", testChatID, testChatID)
+	mh.HandleMoodSelection("calm", testChatID, testChatID)
+
+	// The second selection repicks twice but only fetches an image for "Second"
+	if provider.calls != 4 || images.calls != 2 {
+		t.Errorf("content fetched %d times, images %d times; want 4 and 2", provider.calls, images.calls)
+	}
+	if photos := recorder.Photos(); len(photos) != 2 || !strings.Contains(photos[1].Caption, "Second") {
+		t.Errorf("photos = %+v", photos)
+	}
+}
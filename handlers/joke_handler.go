@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/translation"
+)
+
+// isJokeType reports whether t is a known joke type
+func isJokeType(t string) bool {
+	for _, jokeType := range models.JokeTypes {
+		if t == jokeType {
+			return true
+		}
+	}
+	return false
+}
+
+// jokeMood returns the first mood that serves jokes
+func (mh *MessageHandler) jokeMood() (models.ContentCategory, bool) {
+	for _, category := range mh.registry.Moods() {
+		if kind, err := mh.registry.KindForMood(category.Name); err == nil && kind == models.KindJoke {
+			return category, true
+		}
+	}
+	return models.ContentCategory{}, false
+}
+
+// moodFetch returns how to fetch content for a mood for this user: jokes of the
+// user's preferred type for joke moods, falling back to any joke, otherwise the
+// mood as is. Content written in the user's language is preferred over content
+// that has to be translated, unless the user has seen it recently: native
+// sources are often small and would otherwise keep repeating themselves.
+func (mh *MessageHandler) moodFetch(userID int64, mood string, lang translation.Language) func(ctx context.Context) (models.Content, error) {
+	fetchMood := func(ctx context.Context) (models.Content, error) {
+		if lang != translation.English {
+			content, err := mh.content.FetchNative(ctx, mood, string(lang))
+			if err == nil && !mh.history.SeenRecently(userID, content) {
+				return content, nil
+			}
+		}
+		return mh.content.FetchMood(ctx, mood)
+	}
+
+	jokeType := mh.preferences.GetJokeType(userID)
+	if jokeType == "" {
+		return fetchMood
+	}
+	if kind, err := mh.registry.KindForMood(mood); err != nil || kind != models.KindJoke {
+		return fetchMood
+	}
+	return func(ctx context.Context) (models.Content, error) {
+		content, err := mh.content.FetchJoke(ctx, mood, jokeType)
+		if err != nil {
+			return fetchMood(ctx)
+		}
+		return content, nil
+	}
+}
+
+// HandleJokeCommand handles /joke: with a type such as "programming" it sends a
+// joke of that type, without one it offers the joke type preference keyboard
+func (mh *MessageHandler) HandleJokeCommand(chatID, userID int64, args string) {
+	jokeType := strings.ToLower(strings.TrimSpace(args))
+	if jokeType == "" {
+		mh.SendJokeTypeKeyboard(chatID, userID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.ContentTimeout.Duration)
+	defer cancel()
+
+	category, exists := mh.jokeMood()
+	if !isJokeType(jokeType) || !exists {
+		mh.sendNotice(chatID, mh.localize(chatID, "joke.unknown_type"))
+		mh.SendJokeTypeKeyboard(chatID, userID)
+		return
+	}
+
+	content, err := mh.fetchUnseen(ctx, userID, category.Name, func(ctx context.Context) (models.Content, error) {
+		return mh.content.FetchJoke(ctx, category.Name, jokeType)
+	})
+	if err != nil {
+		fmt.Printf("Error fetching %s jokes: %v\n", jokeType, err)
+		mh.sendNotice(chatID, mh.localize(chatID, "fetch.failed"))
+		return
+	}
+	mh.sendContentWithImage(ctx, chatID, userID, "", category.Name, content)
+}
+
+// SendJokeTypeKeyboard sends the joke type preference keyboard, marking the current choice
+func (mh *MessageHandler) SendJokeTypeKeyboard(chatID, userID int64) {
+	current := mh.preferences.GetJokeType(userID)
+	button := func(jokeType string) tgbotapi.InlineKeyboardButton {
+		label := mh.localize(chatID, "joketype."+jokeType)
+		if jokeType == current || (jokeType == "any" && current == "") {
+			label = "✅ " + label
+		}
+		return tgbotapi.NewInlineKeyboardButtonData(label, "joketype_"+jokeType)
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{{button("any")}}
+	for i, jokeType := range models.JokeTypes {
+		if i%2 == 0 {
+			rows = append(rows, []tgbotapi.InlineKeyboardButton{})
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], button(jokeType))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, mh.localize(chatID, "joke.prompt"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	mh.bot.Send(msg)
+}
+
+// HandleJokeTypeSelection handles joketype_{type} callbacks and returns the callback answer
+func (mh *MessageHandler) HandleJokeTypeSelection(data string, chatID, userID int64) string {
+	choice := strings.TrimPrefix(data, "joketype_")
+	jokeType := choice
+	if choice == "any" {
+		jokeType = ""
+	} else if !isJokeType(choice) {
+		return mh.localize(chatID, "joketype.unknown")
+	}
+
+	if err := mh.preferences.SetJokeType(userID, jokeType); err != nil {
+		return mh.localize(chatID, "joketype.save_failed")
+	}
+	return mh.localize(chatID, "joketype.set."+choice)
+}
+
+// knockKnockDialogue splits a knock-knock joke into its five lines, alternating
+// between the bot and the user: "Knock knock." / "Who's there?" / "Lettuce." /
+// "Lettuce who?" / "Lettuce in, it's cold out here!"
+func knockKnockDialogue(content models.Content) ([]string, bool) {
+	if content.JokeType != models.JokeKnockKnock {
+		return nil, false
+	}
+
+	setup, punchline := splitLines(content.Setup), splitLines(content.Punchline)
+	if len(setup) != 3 || !strings.HasPrefix(strings.ToLower(setup[0]), "knock") {
+		return nil, false
+	}
+	// Some jokes skip the "... who?" line and go straight to the answer
+	if len(punchline) == 1 {
+		punchline = append([]string{strings.TrimRight(setup[2], ".!") + " who?"}, punchline...)
+	}
+	if len(punchline) != 2 {
+		return nil, false
+	}
+	return append(setup, punchline...), true
+}
+
+// splitLines splits text into trimmed, non-empty lines
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// sendKnockKnockStep sends the bot's line for a step of a knock-knock reveal, with header
+// before the first one. Each step but the last carries a button with the user's reply; the
+// last one gets the voting keyboard.
+func (mh *MessageHandler) sendKnockKnockStep(chatID int64, delivery models.Delivery, dialogue []string, step int, header string) {
+	userLang := mh.languageManager.GetUserLanguage(chatID)
+	ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.UITimeout.Duration)
+	defer cancel()
+
+	source := sourceLanguage(delivery.Content.Language)
+	line, _ := mh.translator.TranslateFrom(ctx, dialogue[2*step], source, userLang)
+	if step == 0 {
+		line = header + "🚪 " + line
+	}
+	msg := tgbotapi.NewMessage(chatID, mh.languageManager.Languages().FormatText(line, userLang))
+	msg.ParseMode = "Markdown"
+
+	if reply := 2*step + 1; reply < len(dialogue) {
+		label, _ := mh.translator.TranslateFrom(ctx, dialogue[reply], source, userLang)
+		data := fmt.Sprintf("knock_%s_%d", delivery.ID, step+1)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, data),
+		))
+	} else {
+		msg.ReplyMarkup = mh.voteManager.CreateVotingKeyboard(delivery.Mood, delivery.ID)
+	}
+	mh.bot.Send(msg)
+}
+
+// HandleKnockKnock handles knock_{deliveryID}_{step} callbacks: it removes the tapped
+// button so it can't be pressed twice and sends the next line of the joke
+func (mh *MessageHandler) HandleKnockKnock(data string, chatID int64, messageID int) string {
+	parts := strings.Split(data, "_")
+	if len(parts) != 3 {
+		return mh.localize(chatID, "button.unsupported")
+	}
+	step, err := strconv.Atoi(parts[2])
+	delivery, exists := mh.deliveryStore.Get(parts[1])
+	if err != nil || !exists {
+		return mh.localize(chatID, "joke.unavailable")
+	}
+	dialogue, ok := knockKnockDialogue(delivery.Content)
+	if !ok || step < 1 || 2*step >= len(dialogue) {
+		return mh.localize(chatID, "joke.unavailable")
+	}
+
+	if messageID != 0 {
+		mh.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
+		}))
+	}
+	mh.sendKnockKnockStep(chatID, delivery, dialogue, step, "")
+	return ""
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/telegramtest"
+	"github.com/you/moodbot/translation"
+)
+
+// typedJokeProvider serves one joke per type
+type typedJokeProvider struct {
+	jokes map[string]models.Content
+}
+
+func (p *typedJokeProvider) Name() string             { return "typed" }
+func (p *typedJokeProvider) Kind() models.ContentKind { return models.KindJoke }
+func (p *typedJokeProvider) Fetch(ctx context.Context) (models.Content, error) {
+	return p.jokes[models.JokeGeneral], nil
+}
+func (p *typedJokeProvider) FetchJokeType(ctx context.Context, jokeType string) (models.Content, error) {
+	return p.jokes[jokeType], nil
+}
+
+// newJokeTestHandler is newTestHandler with the funny mood served by typedJokeProvider
+func newJokeTestHandler(t *testing.T) (*MessageHandler, *telegramtest.Recorder) {
+	t.Helper()
+
+	mh, recorder := newTestHandler(t)
+	mh.registry.Register(&typedJokeProvider{jokes: map[string]models.Content{
+		models.JokeGeneral: {Kind: models.KindJoke, Setup: "General setup", Punchline: "General punchline", JokeType: models.JokeGeneral},
+		models.JokeProgramming: {
+			Kind: models.KindJoke, Setup: "Why do programmers prefer dark mode?", Punchline: "Because light attracts bugs.", JokeType: models.JokeProgramming,
+		},
+		models.JokeKnockKnock: {
+			Kind: models.KindJoke, Setup: "Knock knock. \n Who's there? \n Lettuce.", Punchline: "Lettuce in, it's cold out here!", JokeType: models.JokeKnockKnock,
+		},
+	}})
+	mh.registry.AddMood(models.ContentCategory{Name: "funny", Label: "Funny", Emoji: "😂", Providers: []string{"typed"}})
+	return mh, recorder
+}
+
+func TestJokeCommandWithType(t *testing.T) {
+	mh, recorder := newJokeTestHandler(t)
+
+	mh.HandleJokeCommand(testChatID, testChatID, "Programming")
+
+	messages := recorder.Messages()
+	if len(messages) != 1 || !strings.HasPrefix(messages[0].Text, "Why do programmers") {
+		t.Fatalf("messages = %+v, want a programming joke", messages)
+	}
+	// One-off requests don't change the preference
+	if got := mh.preferences.GetJokeType(testChatID); got != "" {
+		t.Errorf("joke type preference = %q, want none", got)
+	}
+}
+
+func TestJokeCommandUnknownType(t *testing.T) {
+	mh, recorder := newJokeTestHandler(t)
+
+	mh.HandleJokeCommand(testChatID, testChatID, "limerick")
+
+	messages := recorder.Messages()
+	if len(messages) != 2 || !strings.Contains(messages[0].Text, "I don't know that joke type") {
+		t.Fatalf("messages = %+v, want a notice and the joke type keyboard", messages)
+	}
+}
+
+func TestJokeTypePreference(t *testing.T) {
+	mh, recorder := newJokeTestHandler(t)
+
+	mh.HandleJokeCommand(testChatID, testChatID, "")
+	wantKeyboard := [][]string{
+		{"✅ Any kind=joketype_any"},
+		{"General=joketype_general", "Programming=joketype_programming"},
+		{"Knock-knock=joketype_knock-knock", "Dad jokes=joketype_dad"},
+	}
+	if got := telegramtest.Buttons(recorder.Messages()[0].ReplyMarkup); !reflect.DeepEqual(got, wantKeyboard) {
+		t.Errorf("buttons = %v, want %v", got, wantKeyboard)
+	}
+
+	if answer := mh.HandleJokeTypeSelection("joketype_programming", testChatID, testChatID); answer != "✅ You'll get programming jokes." {
+		t.Errorf("answer = %q", answer)
+	}
+	recorder.Reset()
+
+	// The funny mood now serves the preferred type
+	mh.HandleMoodSelection("funny", testChatID, testChatID)
+	if text := recorder.Messages()[0].Text; !strings.HasPrefix(text, "Why do programmers") {
+		t.Errorf("funny mood sent %q, want a programming joke", text)
+	}
+
+	if answer := mh.HandleJokeTypeSelection("joketype_limerick", testChatID, testChatID); answer != "Unknown joke type" {
+		t.Errorf("answer = %q", answer)
+	}
+}
+
+func TestKnockKnockReveal(t *testing.T) {
+	mh, recorder := newJokeTestHandler(t)
+
+	mh.HandleJokeCommand(testChatID, testChatID, "knock-knock")
+	steps := []struct {
+		text   string
+		button string
+	}{
+		{"🚪 Knock knock.", "Who's there?"},
+		{"Lettuce.", "Lettuce who?"},
+		{"Lettuce in, it's cold out here!", ""},
+	}
+
+	for i, step := range steps {
+		messages := recorder.Messages()
+		if len(messages) != 1 {
+			t.Fatalf("step %d: got %d messages, want 1", i, len(messages))
+		}
+		if messages[0].Text != step.text {
+			t.Errorf("step %d: text = %q, want %q", i, messages[0].Text, step.text)
+		}
+		buttons := telegramtest.Buttons(messages[0].ReplyMarkup)
+		if step.button == "" {
+			if len(buttons) != 1 || !strings.HasPrefix(buttons[0][0], "👍=vote_funny_") {
+				t.Errorf("last step buttons = %v, want the voting keyboard", buttons)
+			}
+			break
+		}
+		if len(buttons) != 1 || len(buttons[0]) != 1 || !strings.HasPrefix(buttons[0][0], step.button+"=knock_") {
+			t.Fatalf("step %d: buttons = %v, want %q", i, buttons, step.button)
+		}
+
+		data := buttons[0][0][len(step.button)+1:]
+		recorder.Reset()
+		if answer := mh.HandleKnockKnock(data, testChatID, 0); answer != "" {
+			t.Fatalf("step %d: answer = %q", i, answer)
+		}
+	}
+
+	if answer := mh.HandleKnockKnock("knock_missing_1", testChatID, 0); answer != "Sorry, this joke is no longer available." {
+		t.Errorf("answer for an unknown delivery = %q", answer)
+	}
+}
+
+func TestKnockKnockSkipsImageAndKeepsSurpriseHeader(t *testing.T) {
+	mh, recorder := newJokeTestHandler(t)
+	images := &countingImages{}
+	mh.registry.RegisterImageProvider(images)
+	// Every mood that can succeed serves typed jokes, so the surprise is a knock-knock joke
+	for _, mood := range []string{"funny", "inspiring"} {
+		mh.registry.AddMood(models.ContentCategory{Name: mood, Label: mood, Providers: []string{"typed"}, ImageProviders: []string{"counting"}})
+	}
+	if err := mh.preferences.SetJokeType(testChatID, models.JokeKnockKnock); err != nil {
+		t.Fatal(err)
+	}
+
+	mh.HandleJokeCommand(testChatID, testChatID, "knock-knock")
+	mh.HandleSurprise(testChatID, testChatID)
+
+	if images.calls != 0 || len(recorder.Photos()) != 0 {
+		t.Errorf("fetched %d images and sent %d photos for knock-knock jokes, want none", images.calls, len(recorder.Photos()))
+	}
+	messages := recorder.Messages()
+	if len(messages) != 2 || messages[0].Text != "🚪 Knock knock." || messages[1].Text != "🎲 **SURPRISE!** 🚪 Knock knock." {
+		t.Errorf("messages = %+v, want the first steps, the surprise one with its header", messages)
+	}
+}
+
+func TestKnockKnockDialogue(t *testing.T) {
+	content := models.Content{
+		Kind:      models.KindJoke,
+		Setup:     "Knock knock.\nWho's there?\nBoo.",
+		Punchline: "Boo who?\nDon't cry, it's only a joke!",
+		JokeType:  models.JokeKnockKnock,
+	}
+	want := []string{"Knock knock.", "Who's there?", "Boo.", "Boo who?", "Don't cry, it's only a joke!"}
+	if got, ok := knockKnockDialogue(content); !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("dialogue = %q, %v; want %q", got, ok, want)
+	}
+
+	// Jokes that don't follow the pattern are shown whole
+	content.Setup = "Knock knock. Who's there? Boo."
+	if _, ok := knockKnockDialogue(content); ok {
+		t.Error("expected a one-line setup not to be revealed step by step")
+	}
+}
+
+func TestRTLDirectionOnEverySendPath(t *testing.T) {
+	mh, recorder := newJokeTestHandler(t)
+	languages := append(config.Default().Languages, config.LanguageConfig{Code: "ur", Name: "اردو", Direction: "rtl"})
+	mh.languageManager = translation.NewLanguageManager(t.TempDir(), translation.NewLanguageRegistry(languages))
+	mh.languageManager.SetUserLanguage(testChatID, "ur")
+
+	mh.HandleSurprise(testChatID, testChatID)
+	mh.HandleJokeCommand(testChatID, testChatID, "knock-knock")
+	messages := recorder.Messages()
+	data := telegramtest.Buttons(messages[len(messages)-1].ReplyMarkup)[0][0]
+	mh.HandleKnockKnock(data[strings.Index(data, "=")+1:], testChatID, 0)
+
+	for i, msg := range recorder.Messages() {
+		if !strings.HasPrefix(msg.Text, "\u200f") {
+			t.Errorf("message %d = %q, want an RTL mark", i, msg.Text)
+		}
+	}
+	for i, photo := range recorder.Photos() {
+		if !strings.HasPrefix(photo.Caption, "\u200f") {
+			t.Errorf("photo %d = %q, want an RTL mark", i, photo.Caption)
+		}
+	}
+}
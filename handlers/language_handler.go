@@ -1,82 +1,114 @@
 package handlers
 
 import (
-	"fmt"
+	"strings"
 
-	"github.com/you/moodbot/translation"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/router"
+	"github.com/you/moodbot/translation"
 )
 
 // SendLanguageKeyboard sends language selection keyboard (excluding current language)
 func (mh *MessageHandler) SendLanguageKeyboard(chatID int64) {
 	currentLang := mh.languageManager.GetUserLanguage(chatID)
-	
-	// Create keyboard with available languages (excluding current)
+	languages := mh.languageManager.Languages()
+
+	// One button per configured language, except the current one
 	var buttons [][]tgbotapi.InlineKeyboardButton
-	
-	if currentLang != translation.English {
-		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("English 🇺🇸", "lang_en"),
-		})
-	}
-	
-	if currentLang != translation.Hindi {
-		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("हिंदी 🇮🇳", "lang_hi"),
-		})
-	}
-	
-	if currentLang != translation.Tamil {
-		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("தமிழ் 🇮🇳", "lang_ta"),
-		})
+	for _, lang := range languages.Languages() {
+		if lang.Code == currentLang {
+			continue
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.Label(), "lang_"+string(lang.Code)),
+		))
 	}
-	
-	// If somehow no other languages available, show current status
+
+	// If no other languages are configured, show current status
 	if len(buttons) == 0 {
-		var currentLangName string
-		switch currentLang {
-		case translation.English:
-			currentLangName = "English 🇺🇸"
-		case translation.Hindi:
-			currentLangName = "हिंदी 🇮🇳"
-		case translation.Tamil:
-			currentLangName = "தமிழ் 🇮🇳"
-		}
-		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Current language: %s", currentLangName))
+		current, _ := languages.Lookup(currentLang)
+		msg := tgbotapi.NewMessage(chatID, mh.localize(chatID, "language.current", "language", current.Label()))
 		mh.bot.Send(msg)
 		return
 	}
-	
-	msg := tgbotapi.NewMessage(chatID, "🌍 Choose your preferred language / अपनी भाषा चुनें / உங்கள் மொழியைத் தேர்ந்தெடுக்கவும்:")
+
+	msg := tgbotapi.NewMessage(chatID, mh.localize(chatID, "language.prompt"))
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
 	msg.ReplyMarkup = keyboard
 	mh.bot.Send(msg)
 }
 
-// HandleLanguageSelection handles language selection callbacks
+// HandleLanguageSelection handles lang_{code} callbacks
 func (mh *MessageHandler) HandleLanguageSelection(data string, chatID, userID int64) string {
-	var selectedLang translation.Language
-	var response string
-	
+	selected, exists := mh.languageManager.Languages().Lookup(translation.Language(strings.TrimPrefix(data, "lang_")))
+	if !exists {
+		return mh.localize(chatID, "language.unknown")
+	}
+
+	err := mh.languageManager.SetUserLanguage(userID, selected.Code)
+	if err != nil {
+		return mh.localize(chatID, "language.save_failed")
+	}
+
+	// Confirm in the newly chosen language
+	return mh.catalog.T(string(selected.Code), "language.set", "language", selected.Name)
+}
+
+// DetectLanguage is middleware that sets a new user's language from their
+// Telegram app and, once the update is handled, asks whether to keep it.
+// Only private chats are detected, so group members don't each get a prompt.
+func (mh *MessageHandler) DetectLanguage(next router.HandlerFunc) router.HandlerFunc {
+	return func(c *router.Context) {
+		if !c.IsPrivate() {
+			next(c)
+			return
+		}
+		_, detected := mh.languageManager.DetectUserLanguage(c.UserID, c.LanguageCode)
+		next(c)
+		// An explicit choice made by this very update wins over the prompt
+		if detected && mh.languageManager.IsTentative(c.UserID) {
+			mh.SendDetectedLanguagePrompt(c.ChatID, c.UserID)
+		}
+	}
+}
+
+// SendDetectedLanguagePrompt offers to keep or change the language detected for a user
+func (mh *MessageHandler) SendDetectedLanguagePrompt(chatID, userID int64) {
+	lang := mh.languageManager.GetUserLanguage(userID)
+	current, _ := mh.languageManager.Languages().Lookup(lang)
+
+	msg := tgbotapi.NewMessage(chatID, mh.catalog.T(string(lang), "language.detected", "language", current.Label()))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mh.catalog.T(string(lang), "language.keep"), "langdetect_keep"),
+			tgbotapi.NewInlineKeyboardButtonData(mh.catalog.T(string(lang), "language.change"), "langdetect_change"),
+		),
+	)
+	mh.bot.Send(msg)
+}
+
+// HandleDetectedLanguage handles the langdetect_keep and langdetect_change
+// callbacks, removing the prompt's buttons once one is tapped
+func (mh *MessageHandler) HandleDetectedLanguage(data string, chatID, userID int64, messageID int) string {
+	lang := mh.languageManager.GetUserLanguage(userID)
+	var answer string
 	switch data {
-	case "lang_en":
-		selectedLang = translation.English
-		response = "✅ Language set to English!"
-	case "lang_hi":
-		selectedLang = translation.Hindi
-		response = "✅ भाषा हिंदी में सेट की गई!"
-	case "lang_ta":
-		selectedLang = translation.Tamil
-		response = "✅ மொழி தமிழில் அமைக்கப்பட்டது!"
+	case "langdetect_keep":
+		if err := mh.languageManager.ConfirmUserLanguage(userID); err != nil {
+			return mh.catalog.T(string(lang), "language.save_failed")
+		}
+		current, _ := mh.languageManager.Languages().Lookup(lang)
+		answer = mh.catalog.T(string(lang), "language.set", "language", current.Name)
+	case "langdetect_change":
+		mh.SendLanguageKeyboard(chatID)
 	default:
-		return "Unknown language selection"
+		return mh.catalog.T(string(lang), "language.unknown")
 	}
-	
-	err := mh.languageManager.SetUserLanguage(userID, selectedLang)
-	if err != nil {
-		return "Error saving language preference"
+
+	if messageID != 0 {
+		mh.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
+		}))
 	}
-	
-	return response
-}
\ No newline at end of file
+	return answer
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/router"
+	"github.com/you/moodbot/telegramtest"
+	"github.com/you/moodbot/translation"
+)
+
+func TestLanguageFlow(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	// English users are offered the other languages
+	mh.SendLanguageKeyboard(testChatID)
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	want := [][]string{{"हिंदी 🇮🇳=lang_hi"}, {"தமிழ் 🇮🇳=lang_ta"}}
+	if got := telegramtest.Buttons(messages[0].ReplyMarkup); !reflect.DeepEqual(got, want) {
+		t.Errorf("buttons = %v, want %v", got, want)
+	}
+
+	// Picking Hindi stores the preference
+	if response := mh.HandleLanguageSelection("lang_hi", testChatID, testChatID); response != "✅ भाषा हिंदी में सेट की गई!" {
+		t.Errorf("response = %q", response)
+	}
+	if lang := mh.languageManager.GetUserLanguage(testChatID); lang != hindi {
+		t.Errorf("language = %q, want hi", lang)
+	}
+
+	// Hindi is no longer offered
+	recorder.Reset()
+	mh.SendLanguageKeyboard(testChatID)
+	want = [][]string{{"English 🇺🇸=lang_en"}, {"தமிழ் 🇮🇳=lang_ta"}}
+	if got := telegramtest.Buttons(recorder.Messages()[0].ReplyMarkup); !reflect.DeepEqual(got, want) {
+		t.Errorf("buttons = %v, want %v", got, want)
+	}
+
+	// The mood keyboard now comes from the Hindi catalog
+	recorder.Reset()
+	if err := mh.SendMoodKeyboard(testChatID); err != nil {
+		t.Fatal(err)
+	}
+	msg := recorder.Messages()[0]
+	if msg.Text != "आज आपका मूड कैसा है?" {
+		t.Errorf("prompt = %q", msg.Text)
+	}
+	want = [][]string{{"मज़ेदार 😂=funny", "प्रेरक 💡=inspiring", "ज्ञानवर्धक 📚=educational"}}
+	if got := telegramtest.Buttons(msg.ReplyMarkup); !reflect.DeepEqual(got, want) {
+		t.Errorf("buttons = %v, want %v", got, want)
+	}
+}
+
+func TestHandleLanguageSelectionUnknown(t *testing.T) {
+	mh, _ := newTestHandler(t)
+
+	if response := mh.HandleLanguageSelection("lang_xx", testChatID, testChatID); response != "Unknown language selection" {
+		t.Errorf("response = %q", response)
+	}
+	if lang := mh.languageManager.GetUserLanguage(testChatID); lang != translation.English {
+		t.Errorf("language = %q, want en", lang)
+	}
+}
+
+func TestConfiguredLanguage(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	languages := append(config.Default().Languages, config.LanguageConfig{Code: "te", Name: "తెలుగు", Flag: "🇮🇳", Direction: "ltr"})
+	dataDir := t.TempDir()
+	mh.languageManager = translation.NewLanguageManager(dataDir, translation.NewLanguageRegistry(languages))
+
+	mh.SendLanguageKeyboard(testChatID)
+	want := [][]string{{"हिंदी 🇮🇳=lang_hi"}, {"தமிழ் 🇮🇳=lang_ta"}, {"తెలుగు 🇮🇳=lang_te"}}
+	if got := telegramtest.Buttons(recorder.Messages()[0].ReplyMarkup); !reflect.DeepEqual(got, want) {
+		t.Errorf("buttons = %v, want %v", got, want)
+	}
+
+	// Telugu has no locale file, so UI strings fall back to English
+	if response := mh.HandleLanguageSelection("lang_te", testChatID, testChatID); response != "✅ Language set to తెలుగు!" {
+		t.Errorf("response = %q", response)
+	}
+	if lang := mh.languageManager.GetUserLanguage(testChatID); lang != "te" {
+		t.Errorf("language = %q, want te", lang)
+	}
+
+	// Content is machine-translated into Telugu and flagged
+	recorder.Reset()
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	if text := recorder.Messages()[0].Text; text != "🇮🇳 \"[te] Stay hungry, stay foolish.\" — Steve Jobs" {
+		t.Errorf("text = %q", text)
+	}
+
+	// Once Telugu is no longer configured the user is back to English
+	mh.languageManager = translation.NewLanguageManager(dataDir, translation.NewLanguageRegistry(config.Default().Languages))
+	if lang := mh.languageManager.GetUserLanguage(testChatID); lang != translation.English {
+		t.Errorf("language = %q, want en", lang)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	r := router.New(recorder)
+	mh.RegisterRoutes(r)
+
+	// The first /start is answered in the user's Telegram language, then the prompt follows
+	update := commandUpdate(testChatID, "/start")
+	update.Message.From.LanguageCode = "hi"
+	r.Dispatch(update)
+	messages := recorder.Messages()
+	if len(messages) != 2 || messages[0].Text != "आज आपका मूड कैसा है?" {
+		t.Fatalf("messages = %+v", messages)
+	}
+	if !strings.Contains(messages[1].Text, "हिंदी 🇮🇳") {
+		t.Errorf("prompt = %q", messages[1].Text)
+	}
+	want := [][]string{{"✅ रखें=langdetect_keep", "🌍 बदलें=langdetect_change"}}
+	if got := telegramtest.Buttons(messages[1].ReplyMarkup); !reflect.DeepEqual(got, want) {
+		t.Errorf("buttons = %v, want %v", got, want)
+	}
+
+	// Later updates are not prompted again
+	recorder.Reset()
+	r.Dispatch(update)
+	if messages := recorder.Messages(); len(messages) != 1 {
+		t.Errorf("got %d messages, want only the mood keyboard", len(messages))
+	}
+
+	// Keep confirms the detected language
+	if answer := mh.HandleDetectedLanguage("langdetect_keep", testChatID, testChatID, 0); answer != "✅ भाषा हिंदी में सेट की गई!" {
+		t.Errorf("answer = %q", answer)
+	}
+	if mh.languageManager.IsTentative(testChatID) {
+		t.Error("language is still tentative")
+	}
+}
+
+func TestDetectLanguageOverridden(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	r := router.New(recorder)
+	mh.RegisterRoutes(r)
+
+	// Change offers the other languages
+	update := commandUpdate(testChatID, "/start")
+	update.Message.From.LanguageCode = "ta"
+	r.Dispatch(update)
+	recorder.Reset()
+	mh.HandleDetectedLanguage("langdetect_change", testChatID, testChatID, 0)
+	want := [][]string{{"English 🇺🇸=lang_en"}, {"हिंदी 🇮🇳=lang_hi"}}
+	if got := telegramtest.Buttons(recorder.Messages()[0].ReplyMarkup); !reflect.DeepEqual(got, want) {
+		t.Errorf("buttons = %v, want %v", got, want)
+	}
+
+	// An explicit choice wins, and a new user choosing straight away is not prompted
+	mh.HandleLanguageSelection("lang_en", testChatID, testChatID)
+	if lang := mh.languageManager.GetUserLanguage(testChatID); lang != translation.English || mh.languageManager.IsTentative(testChatID) {
+		t.Errorf("language = %q, want an explicit en", lang)
+	}
+
+	recorder.Reset()
+	r.Dispatch(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "1",
+		From:    &tgbotapi.User{ID: 7, LanguageCode: "hi"},
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 7, Type: "private"}},
+		Data:    "lang_ta",
+	}})
+	if messages := recorder.Messages(); len(messages) != 0 {
+		t.Errorf("got %d messages, want no prompt", len(messages))
+	}
+	if lang := mh.languageManager.GetUserLanguage(7); lang != tamil {
+		t.Errorf("language = %q, want ta", lang)
+	}
+}
+
+func TestDetectLanguageInGroup(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	r := router.New(recorder)
+	mh.RegisterRoutes(r)
+	const groupID, userID = -100, 7
+
+	// Group members are neither detected nor prompted
+	update := commandUpdate(userID, "/start")
+	update.Message.Chat = &tgbotapi.Chat{ID: groupID, Type: "group"}
+	update.Message.From.LanguageCode = "hi"
+	r.Dispatch(update)
+	if messages := recorder.Messages(); len(messages) != 1 {
+		t.Errorf("got %d messages, want only the mood keyboard", len(messages))
+	}
+	if lang := mh.languageManager.GetUserLanguage(userID); lang != translation.English {
+		t.Errorf("language = %q, want en", lang)
+	}
+
+	// The prompt and its answers follow the user's language, not the chat's
+	mh.languageManager.DetectUserLanguage(userID, "hi")
+	recorder.Reset()
+	mh.SendDetectedLanguagePrompt(groupID, userID)
+	msg := recorder.Messages()[0]
+	if msg.ChatID != groupID || !strings.Contains(msg.Text, "हिंदी 🇮🇳") {
+		t.Errorf("prompt = %d %q", msg.ChatID, msg.Text)
+	}
+	if answer := mh.HandleDetectedLanguage("langdetect_keep", groupID, userID, 0); answer != "✅ भाषा हिंदी में सेट की गई!" {
+		t.Errorf("answer = %q", answer)
+	}
+	if mh.languageManager.IsTentative(userID) || mh.languageManager.GetUserLanguage(groupID) != translation.English {
+		t.Error("keep should confirm the user's language only")
+	}
+}
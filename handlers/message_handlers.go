@@ -4,60 +4,77 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/delivery"
 	"github.com/you/moodbot/favorites"
+	"github.com/you/moodbot/fetchers"
+	"github.com/you/moodbot/history"
+	"github.com/you/moodbot/i18n"
+	"github.com/you/moodbot/media"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/preferences"
+	"github.com/you/moodbot/router"
 	"github.com/you/moodbot/translation"
 	"github.com/you/moodbot/voting"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // MessageHandler handles all bot message interactions
 type MessageHandler struct {
-	bot             *tgbotapi.BotAPI
+	bot             Sender
 	voteManager     *voting.VoteManager
 	favoriteManager *favorites.FavoriteManager
 	translator      *translation.Translator
 	languageManager *translation.LanguageManager
+	catalog         *i18n.Catalog
+	registry        *fetchers.Registry
+	deliveryStore   *delivery.DeliveryStore
+	history         *history.HistoryStore
+	preferences     *preferences.PreferenceManager
+	fileIDs         *media.FileIDStore
+	content         ContentSource
+	images          ImageTracker
+	cfg             config.HandlersConfig
 }
 
 // NewMessageHandler creates a new message handler
-func NewMessageHandler(bot *tgbotapi.BotAPI, voteManager *voting.VoteManager, favoriteManager *favorites.FavoriteManager, translator *translation.Translator, languageManager *translation.LanguageManager) *MessageHandler {
+func NewMessageHandler(bot Sender, voteManager *voting.VoteManager, favoriteManager *favorites.FavoriteManager, translator *translation.Translator, languageManager *translation.LanguageManager, catalog *i18n.Catalog, registry *fetchers.Registry, deliveryStore *delivery.DeliveryStore, historyStore *history.HistoryStore, preferenceManager *preferences.PreferenceManager, fileIDStore *media.FileIDStore, content ContentSource, images ImageTracker, cfg config.HandlersConfig) *MessageHandler {
 	return &MessageHandler{
 		bot:             bot,
 		voteManager:     voteManager,
 		favoriteManager: favoriteManager,
 		translator:      translator,
 		languageManager: languageManager,
+		catalog:         catalog,
+		registry:        registry,
+		deliveryStore:   deliveryStore,
+		history:         historyStore,
+		preferences:     preferenceManager,
+		fileIDs:         fileIDStore,
+		content:         content,
+		images:          images,
+		cfg:             cfg,
 	}
 }
 
-// SendHelpMessage sends the help message with available commands
+// localize looks up a UI string in the user's language; args fill its placeholders
+func (mh *MessageHandler) localize(chatID int64, id string, args ...interface{}) string {
+	return mh.catalog.T(string(mh.languageManager.GetUserLanguage(chatID)), id, args...)
+}
+
+// Localize returns a catalog message in the language of the update's user, for router middleware
+func (mh *MessageHandler) Localize(c *router.Context, id string) string {
+	return mh.localize(c.UserID, id)
+}
+
+// SendHelpMessage sends the help message with available commands and the configured moods
 func (mh *MessageHandler) SendHelpMessage(chatID int64) {
-	userLang := mh.languageManager.GetUserLanguage(chatID)
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	helpText := `🤖 **MoodBot Commands:**
-
-/start - Choose your mood and get personalized content
-/surprise - Get completely random content (jokes, quotes, or facts)
-/favorites - View and manage your saved favorites
-/language - Change your language preference
-/help - Show this help message
-
-**How it works:**
-• Use /start to select from mood categories (Fun, Inspiring, Motivating, Casual)
-• Use /surprise for random content from any category
-• Vote on content with 👍 or 👎 buttons
-• Save content you love with the ⭐ favorite button
-• Each mood comes with matching images from Unsplash
-
-Enjoy your mood-boosting content! 🎉`
-
-	translatedHelp, _ := mh.translator.TranslateText(ctx, helpText, userLang)
-	
-	msg := tgbotapi.NewMessage(chatID, translatedHelp)
+	var moods []string
+	for _, category := range mh.registry.Moods() {
+		moods = append(moods, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, mh.moodLabel(chatID, category)))
+	}
+	msg := tgbotapi.NewMessage(chatID, mh.localize(chatID, "help", "moods", strings.Join(moods, ", ")))
 	msg.ParseMode = "Markdown"
 	mh.bot.Send(msg)
 }
@@ -65,49 +82,54 @@ Enjoy your mood-boosting content! 🎉`
 // SendFavorites displays user's saved favorites
 func (mh *MessageHandler) SendFavorites(chatID int64, userID int64) {
 	favorites := mh.favoriteManager.GetUserFavorites(userID)
-	
+	userLang := mh.languageManager.GetUserLanguage(chatID)
+	ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.ContentTimeout.Duration)
+	defer cancel()
+
 	if len(favorites) == 0 {
-		msg := tgbotapi.NewMessage(chatID, "⭐ You haven't saved any favorites yet!\n\nUse the ⭐ button on content you like to save it here.")
+		msg := tgbotapi.NewMessage(chatID, mh.localize(chatID, "favorites.empty"))
 		mh.bot.Send(msg)
 		return
 	}
-	
-	headerMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⭐ **Your Favorites** (%d saved)", len(favorites)))
+
+	headerMsg := tgbotapi.NewMessage(chatID, mh.localize(chatID, "favorites.header", "count", len(favorites)))
 	headerMsg.ParseMode = "Markdown"
 	mh.bot.Send(headerMsg)
-	
+
 	for i, fav := range favorites {
 		var text string
 		var keyboard tgbotapi.InlineKeyboardMarkup
-		
+
+		fav = mh.translateFavorite(ctx, fav, userLang)
 		switch fav.Type {
 		case "quote":
-			text = fmt.Sprintf("💡 *Quote #%d*\n\n%s\n\n*— %s*", i+1, fav.Content, fav.Author)
+			text = fmt.Sprintf("%s\n\n%s\n\n*— %s*", mh.localize(chatID, "favorites.quote", "number", i+1), fav.Content, fav.Author)
 		case "joke":
-			text = fmt.Sprintf("😄 *Joke #%d*\n\n%s\n\n%s", i+1, fav.Setup, fav.Punchline)
+			text = fmt.Sprintf("%s\n\n%s\n\n%s", mh.localize(chatID, "favorites.joke", "number", i+1), fav.Setup, fav.Punchline)
 		case "fact":
-			text = fmt.Sprintf("🧠 *Fact #%d*\n\n%s", i+1, fav.Content)
+			text = fmt.Sprintf("%s\n\n%s", mh.localize(chatID, "favorites.fact", "number", i+1), fav.Content)
 		case "image":
-			text = fmt.Sprintf("🖼️ *Image #%d*\n\n%s", i+1, fav.Content)
+			text = fmt.Sprintf("%s\n\n%s", mh.localize(chatID, "favorites.image", "number", i+1), fav.Content)
 		default:
-			text = fmt.Sprintf("📄 *Content #%d*\n\n%s", i+1, fav.Content)
+			text = fmt.Sprintf("%s\n\n%s", mh.localize(chatID, "favorites.other", "number", i+1), fav.Content)
 		}
-		
+		text = mh.languageManager.Languages().FormatText(text, userLang)
+
 		// Add remove button
 		keyboard = tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🗑️ Remove", fmt.Sprintf("favorite_remove_%s", fav.ID)),
+				tgbotapi.NewInlineKeyboardButtonData(mh.localize(chatID, "favorites.remove"), fmt.Sprintf("favorite_remove_%s", fav.ID)),
 			),
 		)
-		
-		if fav.ImageURL != "" && fav.Type == "image" {
+
+		image := fav.Image
+		if image == nil && fav.ImageURL != "" {
+			image = &models.Image{URL: fav.ImageURL}
+		}
+
+		if image != nil {
 			// Send as photo if it has an image
-			photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(fav.ImageURL))
-			photo.Caption = text
-			photo.ParseMode = "Markdown"
-			photo.ReplyMarkup = keyboard
-			_, err := mh.bot.Send(photo)
-			if err != nil {
+			if !mh.sendPhoto(chatID, image, text, keyboard) {
 				// Fallback to text if photo fails
 				msg := tgbotapi.NewMessage(chatID, text)
 				msg.ParseMode = "Markdown"
@@ -124,41 +146,37 @@ func (mh *MessageHandler) SendFavorites(chatID int64, userID int64) {
 	}
 }
 
-// HandleFavoriteCallback processes favorite-related callbacks
-func (mh *MessageHandler) HandleFavoriteCallback(data string, userID int64, originalContent string) string {
-	// For adding favorites, we need to parse the original content
-	// This is a simplified implementation - in a real app, you'd store more context
-	if data == "favorite_add" {
-		// Extract content type and details from the original message
-		contentType := "general"
-		content := originalContent
-		author := ""
-		setup := ""
-		punchline := ""
-		imageURL := ""
-		
-		// Try to detect content type from format
-		if strings.Contains(content, "—") {
-			contentType = "quote"
-			parts := strings.Split(content, "—")
-			if len(parts) >= 2 {
-				content = strings.TrimSpace(parts[0])
-				author = strings.TrimSpace(parts[1])
-			}
-		} else if strings.Contains(content, "\n\n") && !strings.Contains(content, "SURPRISE") {
-			contentType = "joke"
-			parts := strings.Split(content, "\n\n")
-			if len(parts) >= 2 {
-				setup = strings.TrimSpace(parts[0])
-				punchline = strings.TrimSpace(parts[1])
-				content = fmt.Sprintf("%s %s", setup, punchline)
-			}
-		} else {
-			contentType = "fact"
+// HandleVote records a vote_{type}_{deliveryID}_{up/down} callback and returns the callback answer
+func (mh *MessageHandler) HandleVote(data string, chatID, userID int64) string {
+	up, err := mh.voteManager.HandleVote(data, userID)
+	switch {
+	case err != nil:
+		return mh.localize(chatID, "vote.invalid")
+	case up:
+		return mh.localize(chatID, "vote.up")
+	default:
+		return mh.localize(chatID, "vote.down")
+	}
+}
+
+// HandleFavoriteCallback processes favorite-related callbacks.
+// Favorites are saved from the delivery record, so the original untranslated content and image are kept.
+func (mh *MessageHandler) HandleFavoriteCallback(data string, chatID, userID int64) string {
+	if deliveryID, ok := mh.favoriteManager.GetDeliveryID(data); ok {
+		d, exists := mh.deliveryStore.Get(deliveryID)
+		if !exists {
+			return mh.localize(chatID, "favorites.unavailable")
 		}
-		
-		return mh.favoriteManager.HandleFavoriteCallback(data, userID, contentType, content, author, setup, punchline, imageURL)
+		id := mh.favoriteManager.AddFavorite(userID, d.Content)
+		return mh.localize(chatID, "favorites.added", "id", id)
 	}
-	
-	return mh.favoriteManager.HandleFavoriteCallback(data, userID, "", "", "", "", "", "")
-}
\ No newline at end of file
+
+	if favoriteID, ok := mh.favoriteManager.GetRemoveID(data); ok {
+		if mh.favoriteManager.RemoveFavorite(userID, favoriteID) {
+			return mh.localize(chatID, "favorites.removed")
+		}
+		return mh.localize(chatID, "favorites.not_found")
+	}
+
+	return mh.localize(chatID, "favorites.unknown")
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/delivery"
+	"github.com/you/moodbot/favorites"
+	"github.com/you/moodbot/fetchers"
+	"github.com/you/moodbot/history"
+	"github.com/you/moodbot/i18n"
+	"github.com/you/moodbot/media"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/preferences"
+	"github.com/you/moodbot/router"
+	"github.com/you/moodbot/telegramtest"
+	"github.com/you/moodbot/translation"
+	"github.com/you/moodbot/voting"
+)
+
+const testChatID = 42
+
+// Languages used in the tests, as configured by default
+const (
+	hindi translation.Language = "hi"
+	tamil translation.Language = "ta"
+)
+
+// stubProvider returns fixed content or a fixed error
+type stubProvider struct {
+	name    string
+	kind    models.ContentKind
+	content models.Content
+	err     error
+}
+
+func (p *stubProvider) Name() string             { return p.name }
+func (p *stubProvider) Kind() models.ContentKind { return p.kind }
+func (p *stubProvider) Fetch(ctx context.Context) (models.Content, error) {
+	return p.content, p.err
+}
+
+// newTestHandler builds a MessageHandler backed by a recorder, stub providers and a temp data dir.
+// Non-English translations go to a stub server that prefixes the text with the language code,
+// or the language pair when translating from a language other than English.
+func newTestHandler(t *testing.T) (*MessageHandler, *telegramtest.Recorder) {
+	t.Helper()
+
+	translateServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		langpair := r.URL.Query().Get("langpair")
+		prefix := "[" + langpair[strings.Index(langpair, "|")+1:] + "] "
+		if !strings.HasPrefix(langpair, "en|") {
+			prefix = "[" + langpair + "] "
+		}
+		var resp translation.MyMemoryResponse
+		resp.ResponseStatus = 200
+		resp.ResponseData.TranslatedText = prefix + r.URL.Query().Get("q")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(translateServer.Close)
+
+	cfg := config.Default()
+	cfg.Translation.MyMemory.BaseURL = translateServer.URL
+	dataDir := t.TempDir()
+
+	registry := fetchers.NewRegistry()
+	registry.Register(&stubProvider{
+		name: "quotes",
+		kind: models.KindQuote,
+		content: models.Content{
+			Kind:     models.KindQuote,
+			Body:     "Stay hungry, stay foolish.",
+			Author:   "Steve Jobs",
+			Provider: "quotes",
+		},
+	})
+	registry.Register(&stubProvider{
+		name: "jokes",
+		kind: models.KindJoke,
+		content: models.Content{
+			Kind:      models.KindJoke,
+			Setup:     "Why did the gopher cross the road?",
+			Punchline: "To get to the other goroutine.",
+			Provider:  "jokes",
+		},
+	})
+	registry.Register(&stubProvider{name: "broken", kind: models.KindFact, err: errors.New("upstream down")})
+	registry.AddMood(models.ContentCategory{Name: "funny", Label: "Funny", Emoji: "😂", Providers: []string{"jokes"}})
+	registry.AddMood(models.ContentCategory{Name: "inspiring", Label: "Inspiring", Emoji: "💡", Providers: []string{"quotes"}})
+	registry.AddMood(models.ContentCategory{Name: "educational", Label: "Educational", Emoji: "📚", Providers: []string{"broken"}})
+
+	catalog, err := i18n.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := telegramtest.NewRecorder()
+	mh := NewMessageHandler(
+		recorder,
+		voting.NewVoteManager(),
+		favorites.NewFavoriteManager(dataDir),
+		translation.NewTranslator(cfg.Translation, translation.NewTranslationCache(dataDir, cfg.Translation.CacheSize)),
+		translation.NewLanguageManager(dataDir, translation.NewLanguageRegistry(cfg.Languages)),
+		catalog,
+		registry,
+		delivery.NewDeliveryStore(dataDir, cfg.Deliveries),
+		history.NewHistoryStore(dataDir, cfg.History),
+		preferences.NewPreferenceManager(dataDir),
+		media.NewFileIDStore(dataDir),
+		fetchers.NewMoodFetcher(registry), // no images, so content is sent as text
+		nil,
+		cfg.Handlers,
+	)
+	return mh, recorder
+}
+
+// deliveryIDFromKeyboard extracts the delivery ID from a favorite_add_{id} button
+func deliveryIDFromKeyboard(t *testing.T, markup interface{}) string {
+	t.Helper()
+
+	for _, row := range telegramtest.Buttons(markup) {
+		for _, button := range row {
+			if i := strings.Index(button, "=favorite_add_"); i >= 0 {
+				return button[i+len("=favorite_add_"):]
+			}
+		}
+	}
+	t.Fatalf("no favorite button in keyboard %v", telegramtest.Buttons(markup))
+	return ""
+}
+
+func TestSendFavoritesEmpty(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	mh.SendFavorites(testChatID, testChatID)
+
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	want := "⭐ You haven't saved any favorites yet!\n\nUse the ⭐ button on content you like to save it here."
+	if messages[0].Text != want {
+		t.Errorf("text = %q, want %q", messages[0].Text, want)
+	}
+}
+
+func TestHandleFavoriteCallbackSavesDeliveredContent(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	deliveryID := deliveryIDFromKeyboard(t, recorder.Messages()[0].ReplyMarkup)
+
+	response := mh.HandleFavoriteCallback("favorite_add_"+deliveryID, testChatID, testChatID)
+	if !strings.HasPrefix(response, "Added to favorites!") {
+		t.Fatalf("response = %q", response)
+	}
+
+	saved := mh.favoriteManager.GetUserFavorites(testChatID)
+	if len(saved) != 1 {
+		t.Fatalf("got %d favorites, want 1", len(saved))
+	}
+	if saved[0].Type != "quote" || saved[0].Content != "Stay hungry, stay foolish." || saved[0].Author != "Steve Jobs" {
+		t.Errorf("saved favorite = %+v", saved[0])
+	}
+
+	recorder.Reset()
+	mh.SendFavorites(testChatID, testChatID)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want header and one favorite", len(messages))
+	}
+	if messages[0].Text != "⭐ **Your Favorite** (1 saved)" {
+		t.Errorf("header = %q", messages[0].Text)
+	}
+	if messages[1].Text != "💡 *Quote #1*\n\nStay hungry, stay foolish.\n\n*— Steve Jobs*" {
+		t.Errorf("favorite text = %q", messages[1].Text)
+	}
+	wantButtons := [][]string{{"🗑️ Remove=favorite_remove_" + saved[0].ID}}
+	if got := telegramtest.Buttons(messages[1].ReplyMarkup); !reflect.DeepEqual(got, wantButtons) {
+		t.Errorf("buttons = %v, want %v", got, wantButtons)
+	}
+}
+
+func TestSendFavoritesLocalized(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.languageManager.SetUserLanguage(testChatID, hindi)
+
+	mh.SendFavorites(testChatID, testChatID)
+	want := "⭐ आपने अभी तक कोई पसंदीदा नहीं सहेजा है!\n\nजो सामग्री पसंद आए उस पर ⭐ बटन दबाएँ, वह यहाँ सहेजी जाएगी।"
+	if text := recorder.Messages()[0].Text; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	deliveryID := deliveryIDFromKeyboard(t, recorder.Messages()[1].ReplyMarkup)
+	if response := mh.HandleFavoriteCallback("favorite_add_"+deliveryID, testChatID, testChatID); !strings.HasPrefix(response, "पसंदीदा में जोड़ा गया!") {
+		t.Fatalf("response = %q", response)
+	}
+	recorder.Reset()
+
+	// UI strings come from the catalog, the saved quote is machine-translated
+	mh.SendFavorites(testChatID, testChatID)
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want header and one favorite", len(messages))
+	}
+	if messages[0].Text != "⭐ **आपके पसंदीदा** (1 सहेजा गया)" {
+		t.Errorf("header = %q", messages[0].Text)
+	}
+	if messages[1].Text != "🇮🇳 💡 *उद्धरण #1*\n\n[hi] Stay hungry, stay foolish.\n\n*— Steve Jobs*" {
+		t.Errorf("favorite text = %q", messages[1].Text)
+	}
+}
+
+func TestSendFavoritesTranslatesFromSavedLanguage(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.registry.Register(&nativeProvider{stubProvider{name: "hindi", kind: models.KindQuote, err: errors.New("English is not native here")}})
+	mh.registry.AddMood(models.ContentCategory{Name: "inspiring", Label: "Inspiring", Providers: []string{"quotes", "hindi"}})
+	mh.languageManager.SetUserLanguage(testChatID, hindi)
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	deliveryID := deliveryIDFromKeyboard(t, recorder.Messages()[0].ReplyMarkup)
+	mh.HandleFavoriteCallback("favorite_add_"+deliveryID, testChatID, testChatID)
+	mh.languageManager.SetUserLanguage(testChatID, tamil)
+	recorder.Reset()
+
+	mh.SendFavorites(testChatID, testChatID)
+	if messages := recorder.Messages(); len(messages) != 2 || !strings.Contains(messages[1].Text, "[hi|ta] मन के हारे हार है।") {
+		t.Errorf("messages = %+v, want the Hindi quote translated from Hindi", messages)
+	}
+}
+
+func TestHandleFavoriteCallbackUnknownDelivery(t *testing.T) {
+	mh, _ := newTestHandler(t)
+
+	response := mh.HandleFavoriteCallback("favorite_add_deadbeef", testChatID, testChatID)
+	if response != "Sorry, this item is no longer available to save." {
+		t.Errorf("response = %q", response)
+	}
+	if n := mh.favoriteManager.GetFavoriteCount(testChatID); n != 0 {
+		t.Errorf("saved %d favorites, want 0", n)
+	}
+}
+
+func TestSendHelpMessageListsMoods(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	mh.SendHelpMessage(testChatID)
+	if text := recorder.Messages()[0].Text; !strings.Contains(text, "mood categories (Funny, Inspiring, Educational)") {
+		t.Errorf("help = %q", text)
+	}
+}
+
+func TestLocalizeMiddlewareMessages(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.languageManager.SetUserLanguage(testChatID, hindi)
+	r := router.New(recorder)
+	r.Use(router.RateLimit(1, time.Hour, mh.Localize))
+	mh.RegisterRoutes(r)
+
+	update := tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "1",
+		From:    &tgbotapi.User{ID: testChatID},
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: testChatID, Type: "private"}},
+		Data:    "unknown",
+	}}
+	r.Dispatch(update)
+	recorder.Reset()
+	r.Dispatch(update)
+
+	if callbacks := recorder.Callbacks(); len(callbacks) != 1 || callbacks[0].Text != "थोड़ा धीरे! एक पल बाद फिर कोशिश करें।" {
+		t.Errorf("callbacks = %+v", callbacks)
+	}
+}
@@ -2,156 +2,133 @@ package handlers
 
 import (
 	"context"
-	"time"
+	"fmt"
+	"math/rand"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/you/moodbot/fetchers"
 	"github.com/you/moodbot/models"
 	"github.com/you/moodbot/translation"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// SendMoodKeyboard sends the main mood selection keyboard
-func (mh *MessageHandler) SendMoodKeyboard(chatID int64) error {
+// moodLabel returns a mood's button label in the user's language. Moods the
+// catalog has no mood.{name} message for have their configured label machine
+// translated, for users whose language the catalog has.
+func (mh *MessageHandler) moodLabel(chatID int64, category models.ContentCategory) string {
+	id := "mood." + category.Name
+	if mh.catalog.Has(id) {
+		return mh.localize(chatID, id)
+	}
+
 	userLang := mh.languageManager.GetUserLanguage(chatID)
-	
-	// Translate the prompt
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if !mh.catalog.HasLanguage(string(userLang)) {
+		return category.Label
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.UITimeout.Duration)
 	defer cancel()
-	
-	prompt, _ := mh.translator.TranslateText(ctx, "What's your mood today?", userLang)
-	
-	msg := tgbotapi.NewMessage(chatID, prompt)
-	
-	// Translate button labels
-	funnyText, _ := mh.translator.TranslateText(ctx, "Funny", userLang)
-	inspiringText, _ := mh.translator.TranslateText(ctx, "Inspiring", userLang) 
-	educationalText, _ := mh.translator.TranslateText(ctx, "Educational", userLang)
-	relaxingText, _ := mh.translator.TranslateText(ctx, "Relaxing", userLang)
-	adventurousText, _ := mh.translator.TranslateText(ctx, "Adventurous", userLang)
-	thoughtfulText, _ := mh.translator.TranslateText(ctx, "Thoughtful", userLang)
-	
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(funnyText+" 😂", "funny"),
-			tgbotapi.NewInlineKeyboardButtonData(inspiringText+" 💡", "inspiring"),
-			tgbotapi.NewInlineKeyboardButtonData(educationalText+" 📚", "educational"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(relaxingText+" 🌿", "relaxing"),
-			tgbotapi.NewInlineKeyboardButtonData(adventurousText+" 🌟", "adventurous"),
-			tgbotapi.NewInlineKeyboardButtonData(thoughtfulText+" 🤔", "thoughtful"),
-		),
-	)
-	msg.ReplyMarkup = keyboard
+
+	label, _ := mh.translator.TranslateText(ctx, category.Label, userLang)
+	return label
+}
+
+// WarmupTranslations translates the labels moodLabel machine translates into
+// every language the catalog has ahead of time, since the mood keyboard is sent
+// after every piece of content
+func (mh *MessageHandler) WarmupTranslations(ctx context.Context) int {
+	var texts []string
+	for _, category := range mh.registry.Moods() {
+		if !mh.catalog.Has("mood." + category.Name) {
+			texts = append(texts, category.Label)
+		}
+	}
+
+	var langs []translation.Language
+	for _, lang := range mh.languageManager.Languages().Languages() {
+		if mh.catalog.HasLanguage(string(lang.Code)) {
+			langs = append(langs, lang.Code)
+		}
+	}
+	return mh.translator.Warmup(ctx, texts, langs)
+}
+
+// SendMoodKeyboard sends the main mood selection keyboard
+func (mh *MessageHandler) SendMoodKeyboard(chatID int64) error {
+	msg := tgbotapi.NewMessage(chatID, mh.localize(chatID, "mood.prompt"))
+
+	// Three moods per row
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, category := range mh.registry.Moods() {
+		if i%3 == 0 {
+			rows = append(rows, []tgbotapi.InlineKeyboardButton{})
+		}
+		label := mh.moodLabel(chatID, category)
+		if category.Emoji != "" {
+			label += " " + category.Emoji
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], tgbotapi.NewInlineKeyboardButtonData(label, category.Name))
+	}
+
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 	_, err := mh.bot.Send(msg)
 	return err
 }
 
 // HandleMoodSelection handles mood-based content requests
-func (mh *MessageHandler) HandleMoodSelection(data string, chatID int64) {
-	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+func (mh *MessageHandler) HandleMoodSelection(data string, chatID, userID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.ContentTimeout.Duration)
 	defer cancel()
 
-	var body string
-	var fetchErr error
-	var contentType string
-
-	var imageQuery string
-
-	switch data {
-	case "funny":
-		contentType = "funny"
-		imageQuery = "funny"
-		body, fetchErr = fetchers.FetchJoke(ctx)
-	case "inspiring":
-		contentType = "inspiring"
-		imageQuery = "inspiration"
-		body, fetchErr = fetchers.FetchZenQuote(ctx)
-	case "educational":
-		contentType = "educational"
-		imageQuery = "books"
-		body, fetchErr = fetchers.FetchFact(ctx)
-	case "relaxing":
-		contentType = "relaxing"
-		imageQuery = "nature"
-		body, fetchErr = fetchers.FetchZenQuote(ctx)
-	case "adventurous":
-		contentType = "adventurous"
-		imageQuery = "adventure"
-		body, fetchErr = fetchers.FetchFact(ctx)
-	case "thoughtful":
-		contentType = "thoughtful"
-		imageQuery = "meditation"
-		body, fetchErr = fetchers.FetchZenQuote(ctx)
-	default:
-		body = "I don't know that mood yet."
-		contentType = "unknown"
-		imageQuery = ""
+	userLang := mh.languageManager.GetUserLanguage(chatID)
+
+	category, exists := mh.registry.Mood(data)
+	if !exists {
+		mh.sendNotice(chatID, mh.localize(chatID, "mood.unknown"))
+		_ = mh.SendMoodKeyboard(chatID)
+		return
 	}
 
-	if fetchErr != nil || body == "" {
-		body = "Sorry, couldn't fetch content right now. Try again."
+	content, fetchErr := mh.fetchUnseen(ctx, userID, category.Name, mh.moodFetch(userID, category.Name, userLang))
+	if fetchErr != nil {
+		fmt.Printf("Error fetching %s content: %v\n", category.Name, fetchErr)
+		mh.sendNotice(chatID, mh.languageManager.Languages().FormatText(mh.localize(chatID, "fetch.failed"), userLang))
+	} else {
+		mh.sendContentWithImage(ctx, chatID, userID, "", category.Name, content)
 	}
 
-	// Translate content before sending  
-	userLang := mh.languageManager.GetUserLanguage(chatID)
-	translatedBody, _ := mh.translator.TranslateText(ctx, body, userLang)
-	translatedBody = translation.FormatLanguageSpecificText(translatedBody, userLang)
-	
-	mh.sendContentWithImage(chatID, translatedBody, imageQuery, contentType)
-	
 	// Re-show mood keyboard
 	_ = mh.SendMoodKeyboard(chatID)
 }
 
 // HandleSurprise handles the /surprise command
-func (mh *MessageHandler) HandleSurprise(chatID int64) {
-	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+func (mh *MessageHandler) HandleSurprise(chatID, userID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), mh.cfg.ContentTimeout.Duration)
 	defer cancel()
 
-	// Random categories with their corresponding content fetchers and image queries
-	categories := []models.ContentCategory{
-		{Name: "funny", ImageQuery: "funny"},
-		{Name: "inspiring", ImageQuery: "inspiration"},
-		{Name: "educational", ImageQuery: "books"},
-		{Name: "relaxing", ImageQuery: "nature"},
-		{Name: "adventurous", ImageQuery: "adventure"},
-		{Name: "thoughtful", ImageQuery: "meditation"},
+	categories := mh.registry.Moods()
+	if len(categories) == 0 {
+		return
 	}
 
-	// Pick random category
-	randomIndex := int(time.Now().UnixNano()) % len(categories)
-	chosen := categories[randomIndex]
+	userLang := mh.languageManager.GetUserLanguage(chatID)
 
-	var body string
+	// Try moods in random order until one of them returns content
+	var content models.Content
 	var fetchErr error
-
-	// Fetch content based on category
-	switch chosen.Name {
-	case "funny":
-		body, fetchErr = fetchers.FetchJoke(ctx)
-	case "inspiring", "relaxing", "thoughtful":
-		body, fetchErr = fetchers.FetchZenQuote(ctx)
-	case "educational", "adventurous":
-		body, fetchErr = fetchers.FetchFact(ctx)
-	default:
-		body, fetchErr = fetchers.FetchFact(ctx)
+	for n, i := range rand.Perm(len(categories)) {
+		// Each mood gets a share of the time, so a mood whose upstreams hang doesn't use up the rest
+		moodCtx, cancel := fetchers.ShareDeadline(ctx, len(categories)-n)
+		content, fetchErr = mh.fetchUnseen(moodCtx, userID, categories[i].Name, mh.moodFetch(userID, categories[i].Name, userLang))
+		cancel()
+		if fetchErr == nil {
+			break
+		}
 	}
 
-	// Fallback to fact if primary fetch fails
-	if fetchErr != nil || body == "" {
-		body, fetchErr = fetchers.FetchFact(ctx)
-		chosen.ImageQuery = "random"
+	if fetchErr != nil {
+		fmt.Printf("Error fetching surprise content: %v\n", fetchErr)
+		mh.sendNotice(chatID, mh.localize(chatID, "surprise.failed"))
+		return
 	}
 
-	if fetchErr != nil || body == "" {
-		body = "🎲 Surprise! Something unexpected happened - I couldn't fetch content right now. Try again!"
-	}
-
-	// Translate content before sending
-	userLang := mh.languageManager.GetUserLanguage(chatID)
-	translatedBody, _ := mh.translator.TranslateText(ctx, body, userLang)
-	surpriseText, _ := mh.translator.TranslateText(ctx, "🎲 **SURPRISE!** ", userLang)
-	
-	mh.sendContentWithImage(chatID, surpriseText+translatedBody, chosen.ImageQuery, "surprise")
-}
\ No newline at end of file
+	mh.sendContentWithImage(ctx, chatID, userID, mh.localize(chatID, "surprise.header"), "surprise", content)
+}
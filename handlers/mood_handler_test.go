@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/telegramtest"
+	"github.com/you/moodbot/translation"
+)
+
+// moodKeyboard is the keyboard built from the test registry's moods
+var moodKeyboard = [][]string{{"Funny 😂=funny", "Inspiring 💡=inspiring", "Educational 📚=educational"}}
+
+func TestSendMoodKeyboard(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	if err := mh.SendMoodKeyboard(testChatID); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if messages[0].ChatID != testChatID || messages[0].Text != "What's your mood today?" {
+		t.Errorf("message = chat %d %q", messages[0].ChatID, messages[0].Text)
+	}
+	if got := telegramtest.Buttons(messages[0].ReplyMarkup); !reflect.DeepEqual(got, moodKeyboard) {
+		t.Errorf("buttons = %v, want %v", got, moodKeyboard)
+	}
+}
+
+func TestSendMoodKeyboardTranslatesMoodsMissingFromCatalog(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.registry.AddMood(models.ContentCategory{Name: "cozy", Label: "Cozy", Providers: []string{"quotes"}})
+	mh.languageManager.SetUserLanguage(testChatID, "hi")
+
+	if n := mh.WarmupTranslations(context.Background()); n != 2 {
+		t.Errorf("WarmupTranslations = %d, want Cozy in Hindi and Tamil", n)
+	}
+	if err := mh.SendMoodKeyboard(testChatID); err != nil {
+		t.Fatal(err)
+	}
+
+	buttons := telegramtest.Buttons(recorder.Messages()[0].ReplyMarkup)
+	if got := buttons[len(buttons)-1]; !reflect.DeepEqual(got, []string{"[hi] Cozy=cozy"}) {
+		t.Errorf("last row = %v, want the label translated", got)
+	}
+	if stats := mh.translator.CacheStats(); stats.Hits != 1 || stats.Entries != 2 {
+		t.Errorf("stats = %+v, want the warmed-up label served from the cache", stats)
+	}
+}
+
+func TestHandleMoodSelection(t *testing.T) {
+	tests := []struct {
+		mood string
+		text string
+	}{
+		{"inspiring", "\"Stay hungry, stay foolish.\" — Steve Jobs"},
+		{"funny", "Why did the gopher cross the road?\n\nTo get to the other goroutine."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mood, func(t *testing.T) {
+			mh, recorder := newTestHandler(t)
+
+			mh.HandleMoodSelection(tt.mood, testChatID, testChatID)
+
+			messages := recorder.Messages()
+			if len(messages) != 2 {
+				t.Fatalf("got %d messages, want content and mood keyboard", len(messages))
+			}
+			if messages[0].Text != tt.text {
+				t.Errorf("text = %q, want %q", messages[0].Text, tt.text)
+			}
+
+			deliveryID := deliveryIDFromKeyboard(t, messages[0].ReplyMarkup)
+			wantButtons := [][]string{{
+				"👍=vote_" + tt.mood + "_" + deliveryID + "_up",
+				"👎=vote_" + tt.mood + "_" + deliveryID + "_down",
+				"⭐=favorite_add_" + deliveryID,
+			}}
+			if got := telegramtest.Buttons(messages[0].ReplyMarkup); !reflect.DeepEqual(got, wantButtons) {
+				t.Errorf("buttons = %v, want %v", got, wantButtons)
+			}
+
+			d, exists := mh.deliveryStore.Get(deliveryID)
+			if !exists || d.Mood != tt.mood || d.ChatID != testChatID {
+				t.Errorf("delivery = %+v, exists %v", d, exists)
+			}
+
+			if got := telegramtest.Buttons(messages[1].ReplyMarkup); !reflect.DeepEqual(got, moodKeyboard) {
+				t.Errorf("follow-up keyboard = %v, want %v", got, moodKeyboard)
+			}
+			if len(recorder.Photos()) != 0 {
+				t.Errorf("sent %d photos without an image provider", len(recorder.Photos()))
+			}
+		})
+	}
+}
+
+func TestHandleMoodSelectionFetchError(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	mh.HandleMoodSelection("educational", testChatID, testChatID)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want notice and mood keyboard", len(messages))
+	}
+	if messages[0].Text != "Sorry, couldn't fetch content right now. Try again." {
+		t.Errorf("text = %q", messages[0].Text)
+	}
+	if messages[0].ReplyMarkup != nil {
+		t.Errorf("notice has a keyboard: %v", telegramtest.Buttons(messages[0].ReplyMarkup))
+	}
+}
+
+func TestHandleMoodSelectionUnknownMood(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+
+	mh.HandleMoodSelection("grumpy", testChatID, testChatID)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want notice and mood keyboard", len(messages))
+	}
+	if messages[0].Text != "I don't know that mood yet." {
+		t.Errorf("text = %q", messages[0].Text)
+	}
+}
+
+// nativeProvider serves Hindi quotes natively
+type nativeProvider struct {
+	stubProvider
+}
+
+func (p *nativeProvider) Languages() []string { return []string{"hi"} }
+func (p *nativeProvider) FetchLanguage(ctx context.Context, lang string) (models.Content, error) {
+	return models.Content{Kind: models.KindQuote, Body: "मन के हारे हार है।", Author: "कबीर", Language: "hi", Provider: p.name}, nil
+}
+
+func TestHandleMoodSelectionPrefersNativeLanguage(t *testing.T) {
+	tests := []struct {
+		lang translation.Language
+		text string
+	}{
+		{hindi, "🇮🇳 \"मन के हारे हार है।\" — कबीर"},
+		{tamil, "🇮🇳 \"[ta] Stay hungry, stay foolish.\" — Steve Jobs"},
+		{translation.English, "\"Stay hungry, stay foolish.\" — Steve Jobs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.lang), func(t *testing.T) {
+			mh, recorder := newTestHandler(t)
+			mh.registry.Register(&nativeProvider{stubProvider{name: "hindi", kind: models.KindQuote, err: errors.New("English is not native here")}})
+			mh.registry.AddMood(models.ContentCategory{Name: "inspiring", Label: "Inspiring", Providers: []string{"quotes", "hindi"}})
+			mh.languageManager.SetUserLanguage(testChatID, tt.lang)
+
+			mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+			if text := recorder.Messages()[0].Text; text != tt.text {
+				t.Errorf("text = %q, want %q", text, tt.text)
+			}
+		})
+	}
+}
+
+func TestHandleMoodSelectionTranslatesWhenNativeSeen(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	// The Hindi source has a single quote, like a small native corpus after a few taps
+	mh.registry.Register(&nativeProvider{stubProvider{name: "hindi", kind: models.KindQuote, err: errors.New("English is not native here")}})
+	mh.registry.AddMood(models.ContentCategory{Name: "inspiring", Label: "Inspiring", Providers: []string{"quotes", "hindi"}})
+	mh.languageManager.SetUserLanguage(testChatID, hindi)
+
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+	mh.HandleMoodSelection("inspiring", testChatID, testChatID)
+
+	messages := recorder.Messages()
+	want := []string{"🇮🇳 \"मन के हारे हार है।\" — कबीर", "🇮🇳 \"[hi] Stay hungry, stay foolish.\" — Steve Jobs"}
+	if len(messages) != 4 || messages[0].Text != want[0] || messages[2].Text != want[1] {
+		t.Errorf("messages = %+v, want %q then %q", messages, want[0], want[1])
+	}
+}
+
+// hangingProvider takes delay to fail, whatever the context's deadline
+type hangingProvider struct {
+	stubProvider
+	delay time.Duration
+}
+
+func (p *hangingProvider) Fetch(ctx context.Context) (models.Content, error) {
+	time.Sleep(p.delay)
+	return models.Content{}, errors.New("upstream timed out")
+}
+
+// offlineProvider is a stub that serves content without the network, like the corpus
+type offlineProvider struct {
+	stubProvider
+}
+
+func (p *offlineProvider) Offline() bool { return true }
+
+func TestHandleMoodSelectionSlowUpstreamFallsBackOffline(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.cfg.ContentTimeout = config.Duration{Duration: 50 * time.Millisecond}
+	mh.registry.Register(&hangingProvider{stubProvider: stubProvider{name: "hanging", kind: models.KindQuote}, delay: 100 * time.Millisecond})
+	mh.registry.Register(&offlineProvider{stubProvider{name: "offline", kind: models.KindQuote, content: models.Content{
+		Kind: models.KindQuote, Body: "Offline wisdom.", Provider: "offline",
+	}}})
+	mh.registry.AddMood(models.ContentCategory{Name: "calm", Label: "Calm", Providers: []string{"hanging", "offline"}})
+
+	mh.HandleMoodSelection("calm", testChatID, testChatID)
+
+	if text := recorder.Messages()[0].Text; !strings.Contains(text, "Offline wisdom.") {
+		t.Errorf("text = %q, want the offline fallback after the upstream used up the deadline", text)
+	}
+}
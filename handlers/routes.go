@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"github.com/you/moodbot/router"
+)
+
+// RegisterRoutes registers all bot commands and callbacks on the router
+func (mh *MessageHandler) RegisterRoutes(r *router.Router) {
+	// Pick a new user's language from their Telegram app before anything is sent
+	r.Use(mh.DetectLanguage)
+
+	r.Command("start", func(c *router.Context) {
+		_ = mh.SendMoodKeyboard(c.ChatID)
+	})
+	r.Command("surprise", func(c *router.Context) {
+		mh.HandleSurprise(c.ChatID, c.UserID)
+	})
+	r.Command("joke", func(c *router.Context) {
+		mh.HandleJokeCommand(c.ChatID, c.UserID, c.Args)
+	})
+	r.Command("history", func(c *router.Context) {
+		mh.SendHistory(c.ChatID, c.UserID)
+	})
+	favorites := func(c *router.Context) {
+		mh.SendFavorites(c.ChatID, c.UserID)
+	}
+	r.Command("favorites", favorites)
+	r.Command("favorite", favorites)
+	language := func(c *router.Context) {
+		mh.SendLanguageKeyboard(c.ChatID)
+	}
+	r.Command("language", language)
+	r.Command("lang", language)
+	help := func(c *router.Context) {
+		mh.SendHelpMessage(c.ChatID)
+	}
+	r.Command("help", help)
+	r.DefaultCommand(help)
+
+	// Admin only; other users get no reply
+	r.Command("status", func(c *router.Context) {
+		mh.SendStatus(c.ChatID)
+	}, router.Auth(router.AllowUsers(mh.cfg.AdminIDs...), mh.Localize))
+
+	// Voting
+	r.CallbackPrefix("vote_", func(c *router.Context) {
+		c.Answer(mh.HandleVote(c.Data, c.ChatID, c.UserID))
+	})
+
+	// Language selection
+	r.CallbackPrefix("lang_", func(c *router.Context) {
+		c.Answer(mh.HandleLanguageSelection(c.Data, c.ChatID, c.UserID))
+	})
+	r.CallbackPrefix("langdetect_", func(c *router.Context) {
+		messageID := 0
+		if c.Update.CallbackQuery.Message != nil {
+			messageID = c.Update.CallbackQuery.Message.MessageID
+		}
+		c.Answer(mh.HandleDetectedLanguage(c.Data, c.ChatID, c.UserID, messageID))
+	})
+
+	// Favorites
+	r.CallbackPrefix("favorite_", func(c *router.Context) {
+		c.Answer(mh.HandleFavoriteCallback(c.Data, c.ChatID, c.UserID))
+	})
+
+	// Photo descriptions, shown as an alert so screen readers pick them up
+	r.CallbackPrefix("alt_", func(c *router.Context) {
+		c.AnswerAlert(mh.HandleAltText(c.Data, c.ChatID))
+	})
+
+	// Jokes
+	r.CallbackPrefix("joketype_", func(c *router.Context) {
+		c.Answer(mh.HandleJokeTypeSelection(c.Data, c.ChatID, c.UserID))
+	})
+	r.CallbackPrefix("knock_", func(c *router.Context) {
+		messageID := 0
+		if c.Update.CallbackQuery.Message != nil {
+			messageID = c.Update.CallbackQuery.Message.MessageID
+		}
+		c.Answer(mh.HandleKnockKnock(c.Data, c.ChatID, messageID))
+	})
+
+	// Mood selection
+	for _, category := range mh.registry.Moods() {
+		r.Callback(category.Name, func(c *router.Context) {
+			// Acknowledge callback (remove "loading") before the slow fetch
+			c.Answer(mh.localize(c.ChatID, "mood.working"))
+			mh.HandleMoodSelection(c.Data, c.ChatID, c.UserID)
+		})
+	}
+
+	r.DefaultCallback(func(c *router.Context) {
+		c.Answer(mh.localize(c.ChatID, "button.unsupported"))
+	})
+}
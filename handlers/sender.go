@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/models"
+)
+
+// Sender is the part of the Telegram client the handlers use.
+// *tgbotapi.BotAPI implements it; tests use telegramtest.Recorder.
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// ContentSource supplies ready-to-send content, including its image, for a mood.
+// fetchers.MoodFetcher fetches live; prefetch.Prefetcher serves from a buffer first.
+type ContentSource interface {
+	FetchMood(ctx context.Context, mood string) (models.Content, error)
+	// FetchJoke fetches a joke of a given type, e.g. "programming", for a joke mood
+	FetchJoke(ctx context.Context, mood, jokeType string) (models.Content, error)
+	// FetchNative fetches content written in lang, e.g. "hi", failing when the mood has no native source for it
+	FetchNative(ctx context.Context, mood, lang string) (models.Content, error)
+	// AddImage adds the mood's image to content fetched with fetchers.WithoutImage
+	AddImage(ctx context.Context, mood string, content models.Content) models.Content
+}
+
+// ImageTracker is told whenever an image is shown, for sources such as Unsplash
+// that require a download ping. *fetchers.MoodFetcher implements it.
+type ImageTracker interface {
+	TrackDownload(ctx context.Context, image models.Image) error
+}
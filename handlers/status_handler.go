@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/fetchers"
+	"github.com/you/moodbot/health"
+	"github.com/you/moodbot/i18n"
+)
+
+// SendStatus sends admins a table of provider health, whether Unsplash is
+// configured and how each translation backend is doing. It is not translated.
+func (mh *MessageHandler) SendStatus(chatID int64) {
+	providers := mh.registry.Health()
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "provider\tkind\tok\tfail\tp50\tp95\tp99\tcircuit")
+	for _, p := range providers {
+		circuit := p.Circuit
+		if !p.Configured {
+			circuit = "unconfigured"
+		} else if circuit == "" {
+			circuit = "-"
+		}
+		if p.Health == nil {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\t%s\n", p.Name, p.Kind, circuit)
+			continue
+		}
+		h := p.Health
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n", p.Name, p.Kind, h.Successes, h.Failures,
+			formatLatency(h.P50), formatLatency(h.P95), formatLatency(h.P99), circuit)
+	}
+	w.Flush()
+
+	var text strings.Builder
+	text.WriteString("📊 **Status**\n\n```\n")
+	text.WriteString(codeSafe(table.String()))
+	text.WriteString("```\n")
+
+	var errs []string
+	for _, p := range providers {
+		if p.Health != nil && p.Health.LastError != "" {
+			errs = append(errs, mh.lastErrorLine(p.Name, *p.Health))
+		}
+	}
+	if len(errs) > 0 {
+		text.WriteString("\n**Last errors:**\n")
+		text.WriteString(strings.Join(errs, "\n"))
+		text.WriteString("\n")
+	}
+
+	unsplashKey := "❌ not set"
+	for _, p := range providers {
+		if p.Name == fetchers.UnsplashName && p.Configured {
+			unsplashKey = "✅ set"
+		}
+	}
+	fmt.Fprintf(&text, "\n**UNSPLASH\\_ACCESS\\_KEY:** %s\n", unsplashKey)
+
+	for _, b := range mh.translator.Backends() {
+		t := b.Health
+		quota := "no quota"
+		if b.QuotaLimit > 0 {
+			quota = fmt.Sprintf("%d/%d chars today", b.QuotaUsed, b.QuotaLimit)
+		}
+		fmt.Fprintf(&text, "**Translator (%s):** %d ok, %d failed, p50 %s, p95 %s, %s\n",
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, b.Name), t.Successes, t.Failures, formatLatency(t.P50), formatLatency(t.P95), quota)
+		if t.LastError != "" {
+			text.WriteString(mh.lastErrorLine(b.Name, t) + "\n")
+		}
+	}
+	cache := mh.translator.CacheStats()
+	hitRate := 0
+	if lookups := cache.Hits + cache.Misses; lookups > 0 {
+		hitRate = int(cache.Hits * 100 / lookups)
+	}
+	fmt.Fprintf(&text, "**Translation cache:** %d entries, %d%% hits (%d hits, %d misses)\n",
+		cache.Entries, hitRate, cache.Hits, cache.Misses)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "Markdown"
+	mh.bot.Send(msg)
+}
+
+// lastErrorLine describes an upstream's last error, e.g. "• zenquotes: status 503 (2m ago)"
+func (mh *MessageHandler) lastErrorLine(name string, h health.Snapshot) string {
+	return fmt.Sprintf("• %s: %s (%s)", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, name),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, h.LastError), timeAgo(mh.catalog, i18n.DefaultLanguage, time.Since(h.LastErrorAt)))
+}
+
+// formatLatency formats a latency to the millisecond, or "-" when nothing was measured
+func formatLatency(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "-"
+	case d < time.Millisecond:
+		return "<1ms"
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
+
+// codeSafe keeps text from closing the Markdown code block it is placed in
+func codeSafe(text string) string {
+	return strings.ReplaceAll(text, "`", "'")
+}
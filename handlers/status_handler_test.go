@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/fetchers"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/router"
+)
+
+// commandUpdate builds an update for a command sent by userID in their private chat
+func commandUpdate(userID int64, command string) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:     command,
+		From:     &tgbotapi.User{ID: userID},
+		Chat:     &tgbotapi.Chat{ID: userID, Type: "private"},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}},
+	}}
+}
+
+func TestStatusAdminOnly(t *testing.T) {
+	mh, recorder := newTestHandler(t)
+	mh.cfg.AdminIDs = []int64{testChatID}
+	cfg := config.Default()
+	cfg.Resilience.Retry.MaxAttempts = 1
+	mh.registry.Register(fetchers.NewResilientProvider(&stubProvider{name: "broken", kind: models.KindFact, err: errors.New("upstream down")}, cfg.Resilience))
+	mh.registry.RegisterImageProvider(fetchers.NewUnsplashClient(cfg.APIs.Unsplash))
+	mh.HandleMoodSelection("educational", testChatID, testChatID)
+	recorder.Reset()
+
+	r := router.New(recorder)
+	mh.RegisterRoutes(r)
+
+	r.Dispatch(commandUpdate(7, "/status"))
+	if sent := recorder.Sent(); len(sent) != 0 {
+		t.Fatalf("non-admin got %d replies, want none", len(sent))
+	}
+
+	r.Dispatch(commandUpdate(testChatID, "/status"))
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want the status", len(messages))
+	}
+	text := messages[0].Text
+	rows := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		if fields := strings.Fields(line); len(fields) == 8 {
+			rows[fields[0]] = strings.Join(append(fields[1:4], fields[7]), " ")
+		}
+	}
+	for name, want := range map[string]string{
+		"broken":   "fact 0 1 closed",
+		"quotes":   "quote - - -",
+		"unsplash": "image 0 0 unconfigured",
+	} {
+		if rows[name] != want {
+			t.Errorf("%s row = %q, want %q", name, rows[name], want)
+		}
+	}
+	for _, want := range []string{
+		"• broken: upstream down (just now)",
+		"**UNSPLASH\\_ACCESS\\_KEY:** ❌ not set",
+		"**Translator (mymemory):** 0 ok, 0 failed, p50 -, p95 -, 0/5000 chars today",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("status is missing %q:\n%s", want, text)
+		}
+	}
+}
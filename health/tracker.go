@@ -0,0 +1,111 @@
+package health
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSamples is how many recent latencies percentiles are computed over
+const maxSamples = 200
+
+// Snapshot is a point-in-time view of an upstream's health
+type Snapshot struct {
+	Successes   int64
+	Failures    int64
+	P50         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+	LastError   string
+	LastErrorAt time.Time
+}
+
+// Calls returns the total number of calls recorded
+func (s Snapshot) Calls() int64 {
+	return s.Successes + s.Failures
+}
+
+// Tracker records the outcome and latency of calls to one upstream
+type Tracker struct {
+	successes   int64
+	failures    int64
+	latencies   []time.Duration // ring buffer of the most recent calls
+	next        int
+	lastError   string
+	lastErrorAt time.Time
+	mutex       sync.Mutex
+}
+
+// NewTracker creates an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{latencies: make([]time.Duration, 0, maxSamples)}
+}
+
+// Record records one call that took latency and failed with err, or succeeded if err is nil
+func (t *Tracker) Record(latency time.Duration, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if err != nil {
+		t.failures++
+		t.lastError = ErrorText(err)
+		t.lastErrorAt = time.Now()
+	} else {
+		t.successes++
+	}
+
+	if len(t.latencies) < maxSamples {
+		t.latencies = append(t.latencies, latency)
+	} else {
+		t.latencies[t.next] = latency
+	}
+	t.next = (t.next + 1) % maxSamples
+}
+
+// Snapshot returns the counts, latency percentiles and last error recorded so far
+func (t *Tracker) Snapshot() Snapshot {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	sorted := make([]time.Duration, len(t.latencies))
+	copy(sorted, t.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return Snapshot{
+		Successes:   t.successes,
+		Failures:    t.failures,
+		P50:         percentile(sorted, 50),
+		P95:         percentile(sorted, 95),
+		P99:         percentile(sorted, 99),
+		LastError:   t.lastError,
+		LastErrorAt: t.lastErrorAt,
+	}
+}
+
+// ErrorText describes err without the query string of a failed request's URL,
+// which may carry an API key
+func ErrorText(err error) string {
+	text := err.Error()
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if i := strings.IndexByte(urlErr.URL, '?'); i >= 0 {
+			text = strings.ReplaceAll(text, urlErr.URL, urlErr.URL[:i])
+		}
+	}
+	return text
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies, or 0 if there are none
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,56 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTrackerSnapshot(t *testing.T) {
+	tracker := NewTracker()
+	if s := tracker.Snapshot(); s.Calls() != 0 || s.P50 != 0 {
+		t.Errorf("empty snapshot = %+v", s)
+	}
+
+	for i := 1; i <= 100; i++ {
+		tracker.Record(time.Duration(i)*time.Millisecond, nil)
+	}
+	tracker.Record(500*time.Millisecond, errors.New("unexpected status 503"))
+
+	s := tracker.Snapshot()
+	if s.Successes != 100 || s.Failures != 1 || s.Calls() != 101 {
+		t.Errorf("counts = %d/%d", s.Successes, s.Failures)
+	}
+	if s.P50 != 51*time.Millisecond || s.P95 != 96*time.Millisecond || s.P99 != 100*time.Millisecond {
+		t.Errorf("percentiles = %s %s %s", s.P50, s.P95, s.P99)
+	}
+	if s.LastError != "unexpected status 503" || s.LastErrorAt.IsZero() {
+		t.Errorf("last error = %q at %s", s.LastError, s.LastErrorAt)
+	}
+}
+
+func TestTrackerKeepsRecentLatencies(t *testing.T) {
+	tracker := NewTracker()
+	for i := 0; i < maxSamples; i++ {
+		tracker.Record(time.Second, nil)
+	}
+	for i := 0; i < maxSamples; i++ {
+		tracker.Record(time.Millisecond, nil)
+	}
+
+	if s := tracker.Snapshot(); s.P99 != time.Millisecond || s.Successes != 2*maxSamples {
+		t.Errorf("snapshot = %+v, want only the recent latencies in the percentiles", s)
+	}
+}
+
+func TestTrackerStripsURLQuery(t *testing.T) {
+	tracker := NewTracker()
+	err := &url.Error{Op: "Get", URL: "https://api.example.com/get?q=hi&key=secret", Err: errors.New("timeout")}
+	tracker.Record(time.Second, fmt.Errorf("mymemory: %w", err))
+
+	if s := tracker.Snapshot(); s.LastError != `mymemory: Get "https://api.example.com/get": timeout` {
+		t.Errorf("last error = %q", s.LastError)
+	}
+}
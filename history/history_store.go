@@ -0,0 +1,200 @@
+package history
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+	"github.com/you/moodbot/storage"
+)
+
+// Entry records one item delivered to a user
+type Entry struct {
+	Fingerprint string             `json:"fingerprint"`
+	Kind        models.ContentKind `json:"kind"`
+	Mood        string             `json:"mood"`
+	Summary     string             `json:"summary"` // short preview of the original content
+	DeliveryID  string             `json:"delivery_id"`
+	SeenAt      int64              `json:"seen_at"`
+}
+
+// summaryLength is how many characters of content are kept for the history view
+const summaryLength = 80
+
+// saveEvery is how many deliveries are recorded between writes to disk
+const saveEvery = 20
+
+// HistoryStore keeps a per-user history of delivered content so repeats can be avoided
+type HistoryStore struct {
+	users      map[int64][]Entry // oldest first
+	window     time.Duration
+	maxPerUser int
+	unsaved    int
+	mutex      sync.RWMutex
+	saveMutex  sync.Mutex // orders writes to disk, which happen outside mutex
+	dataDir    string
+}
+
+// NewHistoryStore creates a new history store persisted in dataDir
+func NewHistoryStore(dataDir string, cfg config.HistoryConfig) *HistoryStore {
+	hs := &HistoryStore{
+		users:      make(map[int64][]Entry),
+		window:     cfg.Window.Duration,
+		maxPerUser: cfg.MaxPerUser,
+		dataDir:    dataDir,
+	}
+
+	// Create data directory if it doesn't exist
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		fmt.Printf("Warning: Could not create history data directory: %v\n", err)
+	}
+
+	hs.loadHistory()
+
+	return hs
+}
+
+// Fingerprint identifies content regardless of case, spacing or which provider served it
+func Fingerprint(content models.Content) string {
+	text := content.Body
+	if content.Kind == models.KindJoke {
+		text = content.Setup + "\n" + content.Punchline
+	}
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	sum := sha1.Sum([]byte(string(content.Kind) + "|" + normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Record adds a delivered item to a user's history
+func (hs *HistoryStore) Record(userID int64, mood, deliveryID string, content models.Content) {
+	if hs.record(userID, mood, deliveryID, content) {
+		if err := hs.saveHistory(); err != nil {
+			fmt.Printf("Error saving history: %v\n", err)
+		}
+	}
+}
+
+// record adds a delivered item to a user's history and reports whether enough
+// have built up to save
+func (hs *HistoryStore) record(userID int64, mood, deliveryID string, content models.Content) bool {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+
+	entries := append(hs.users[userID], Entry{
+		Fingerprint: Fingerprint(content),
+		Kind:        content.Kind,
+		Mood:        mood,
+		Summary:     summarize(content),
+		DeliveryID:  deliveryID,
+		SeenAt:      time.Now().Unix(),
+	})
+	if len(entries) > hs.maxPerUser {
+		entries = entries[len(entries)-hs.maxPerUser:]
+	}
+	hs.users[userID] = entries
+
+	hs.unsaved++
+	return hs.unsaved >= saveEvery
+}
+
+// SeenRecently reports whether the user was shown content within the history window
+func (hs *HistoryStore) SeenRecently(userID int64, content models.Content) bool {
+	hs.mutex.RLock()
+	defer hs.mutex.RUnlock()
+
+	fingerprint := Fingerprint(content)
+	cutoff := time.Now().Add(-hs.window).Unix()
+	entries := hs.users[userID]
+	for i := len(entries) - 1; i >= 0 && entries[i].SeenAt >= cutoff; i-- {
+		if entries[i].Fingerprint == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// Recent returns up to limit of a user's most recent entries, newest first
+func (hs *HistoryStore) Recent(userID int64, limit int) []Entry {
+	hs.mutex.RLock()
+	defer hs.mutex.RUnlock()
+
+	entries := hs.users[userID]
+	result := make([]Entry, 0, limit)
+	for i := len(entries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, entries[i])
+	}
+	return result
+}
+
+// Close flushes history to disk
+func (hs *HistoryStore) Close() error {
+	return hs.saveHistory()
+}
+
+// summarize returns a one-line preview of content
+func summarize(content models.Content) string {
+	text := content.Body
+	if content.Kind == models.KindJoke {
+		text = content.Setup
+	}
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) > summaryLength {
+		return string(runes[:summaryLength-1]) + "…"
+	}
+	return text
+}
+
+// getFilePath returns the file path for stored history
+func (hs *HistoryStore) getFilePath() string {
+	return filepath.Join(hs.dataDir, "history.json")
+}
+
+// loadHistory loads history from file
+func (hs *HistoryStore) loadHistory() {
+	data, err := os.ReadFile(hs.getFilePath())
+	if err != nil {
+		// File doesn't exist, that's okay
+		return
+	}
+
+	if err := json.Unmarshal(data, &hs.users); err != nil {
+		fmt.Printf("Warning: Could not load history: %v\n", err)
+	}
+}
+
+// saveHistory saves history to file. Only taking the snapshot holds the lock,
+// so lookups aren't blocked while the file is written.
+func (hs *HistoryStore) saveHistory() error {
+	hs.saveMutex.Lock()
+	defer hs.saveMutex.Unlock()
+
+	hs.mutex.Lock()
+	users := make(map[int64][]Entry, len(hs.users))
+	for userID, entries := range hs.users {
+		users[userID] = append([]Entry(nil), entries...)
+	}
+	hs.unsaved = 0
+	hs.mutex.Unlock()
+
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling history: %v", err)
+	}
+
+	if err := storage.WriteFileAtomic(hs.getFilePath(), data, 0644); err != nil {
+		return fmt.Errorf("error writing history file: %v", err)
+	}
+
+	return nil
+}
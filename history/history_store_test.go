@@ -0,0 +1,124 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+)
+
+var testConfig = config.HistoryConfig{Window: config.Duration{Duration: time.Hour}, MaxPerUser: 3}
+
+func quote(text string) models.Content {
+	return models.Content{Kind: models.KindQuote, Body: text, Author: "Someone"}
+}
+
+func TestFingerprintIgnoresCaseSpacingAndProvider(t *testing.T) {
+	a := quote("Stay  hungry, stay foolish.")
+	b := quote("stay hungry, stay foolish.")
+	b.Provider = "other"
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Error("fingerprints differ for the same text")
+	}
+	if Fingerprint(a) == Fingerprint(models.Content{Kind: models.KindFact, Body: a.Body}) {
+		t.Error("fingerprints should differ across kinds")
+	}
+}
+
+func TestSeenRecently(t *testing.T) {
+	hs := NewHistoryStore(t.TempDir(), testConfig)
+	hs.Record(1, "inspiring", "d1", quote("First"))
+
+	if !hs.SeenRecently(1, quote("First")) {
+		t.Error("recorded content should be seen")
+	}
+	if hs.SeenRecently(2, quote("First")) {
+		t.Error("history should be per user")
+	}
+	if hs.SeenRecently(1, quote("Second")) {
+		t.Error("unrecorded content should not be seen")
+	}
+
+	// Entries older than the window don't count
+	hs.users[1][0].SeenAt = time.Now().Add(-2 * time.Hour).Unix()
+	if hs.SeenRecently(1, quote("First")) {
+		t.Error("content outside the window should not count as seen")
+	}
+}
+
+func TestRecentIsNewestFirstAndCapped(t *testing.T) {
+	dataDir := t.TempDir()
+	hs := NewHistoryStore(dataDir, testConfig)
+	for _, text := range []string{"one", "two", "three", "four"} {
+		hs.Record(1, "inspiring", text, quote(text))
+	}
+
+	recent := hs.Recent(1, 10)
+	if len(recent) != testConfig.MaxPerUser {
+		t.Fatalf("got %d entries, want %d", len(recent), testConfig.MaxPerUser)
+	}
+	if recent[0].Summary != "four" || recent[2].Summary != "two" {
+		t.Errorf("recent = %+v, want newest first", recent)
+	}
+
+	// History survives a restart
+	if err := hs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := NewHistoryStore(dataDir, testConfig)
+	if got := reloaded.Recent(1, 1); len(got) != 1 || got[0].DeliveryID != "four" {
+		t.Errorf("reloaded recent = %+v", got)
+	}
+}
+
+func TestRecordBatchesSaves(t *testing.T) {
+	dataDir := t.TempDir()
+	hs := NewHistoryStore(dataDir, config.HistoryConfig{Window: testConfig.Window, MaxPerUser: 100})
+	for i := 0; i < saveEvery-1; i++ {
+		hs.Record(int64(i), "inspiring", "d", quote("text"))
+	}
+	if _, err := os.Stat(hs.getFilePath()); !os.IsNotExist(err) {
+		t.Fatalf("history was written before %d deliveries", saveEvery)
+	}
+
+	hs.Record(0, "inspiring", "d", quote("last"))
+	if got := NewHistoryStore(dataDir, testConfig).Recent(0, 1); len(got) != 1 || got[0].Summary != "last" {
+		t.Errorf("reloaded recent = %+v, want the batch saved", got)
+	}
+}
+
+func TestConcurrentRecordsAndSaves(t *testing.T) {
+	dataDir := t.TempDir()
+	cfg := config.HistoryConfig{Window: testConfig.Window, MaxPerUser: 100}
+	hs := NewHistoryStore(dataDir, cfg)
+
+	// Saves take a snapshot and write it outside the lock while other users keep recording
+	var wg sync.WaitGroup
+	for userID := int64(1); userID <= 4; userID++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			for i := 0; i < saveEvery*2; i++ {
+				hs.Record(userID, "inspiring", "d", quote(fmt.Sprintf("quote %d", i)))
+				if !hs.SeenRecently(userID, quote(fmt.Sprintf("quote %d", i))) {
+					t.Errorf("user %d has not seen quote %d right after it was recorded", userID, i)
+				}
+			}
+		}(userID)
+	}
+	wg.Wait()
+	if err := hs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewHistoryStore(dataDir, cfg)
+	for userID := int64(1); userID <= 4; userID++ {
+		if n := len(reloaded.Recent(userID, 100)); n != saveEvery*2 {
+			t.Errorf("reloaded %d entries for user %d, want %d", n, userID, saveEvery*2)
+		}
+	}
+}
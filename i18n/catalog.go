@@ -0,0 +1,241 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// DefaultLanguage is the language every message must exist in; other languages
+// fall back to it for messages they don't translate
+const DefaultLanguage = "en"
+
+// placeholder matches {name} in a message
+var placeholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// pluralForms are the CLDR plural categories a message may define
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+// pluralRules pick the plural category for a count, per language. Languages
+// without a rule use English's.
+var pluralRules = map[string]func(n int) string{
+	"en": oneOther,
+	"hi": zeroOrOne,
+	"bn": zeroOrOne,
+	"ta": oneOther,
+	"te": oneOther,
+}
+
+// oneOther is the rule for languages with a singular for exactly one
+func oneOther(n int) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// zeroOrOne is the rule for languages that use the singular for zero too
+func zeroOrOne(n int) string {
+	if n == 0 || n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// Message is a catalog entry: a single string, or one string per plural category
+type Message struct {
+	Text   string
+	Plural map[string]string
+}
+
+// UnmarshalJSON accepts either "text" or {"one": "...", "other": "..."}
+func (m *Message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.Text); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.Plural); err != nil {
+		return fmt.Errorf("message must be a string or an object of plural forms")
+	}
+	for form := range m.Plural {
+		if !pluralForms[form] {
+			return fmt.Errorf("unknown plural form %q", form)
+		}
+	}
+	if m.Plural["other"] == "" {
+		return fmt.Errorf("plural message has no \"other\" form")
+	}
+	return nil
+}
+
+// texts returns every string in the message
+func (m Message) texts() []string {
+	if m.Plural == nil {
+		return []string{m.Text}
+	}
+	var texts []string
+	for _, text := range m.Plural {
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+// Catalog maps message IDs to UI strings in each language
+type Catalog struct {
+	messages map[string]map[string]Message // language -> message ID -> message
+}
+
+// Load loads the catalog embedded in the binary, one locales/<language>.json per language
+func Load() (*Catalog, error) {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{messages: make(map[string]map[string]Message)}
+	for _, f := range files {
+		data, err := locales.ReadFile("locales/" + f.Name())
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]Message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("locale %s: %v", f.Name(), err)
+		}
+		c.messages[strings.TrimSuffix(f.Name(), path.Ext(f.Name()))] = messages
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validate checks that every translated message exists in the default language
+// and uses no placeholders the default language doesn't
+func (c *Catalog) validate() error {
+	base, exists := c.messages[DefaultLanguage]
+	if !exists {
+		return fmt.Errorf("locale %s.json is missing", DefaultLanguage)
+	}
+
+	for lang, messages := range c.messages {
+		for id, message := range messages {
+			baseMessage, exists := base[id]
+			if !exists {
+				return fmt.Errorf("locale %s: message %q is not in %s.json", lang, id, DefaultLanguage)
+			}
+			want := placeholders(baseMessage)
+			for name := range placeholders(message) {
+				if !want[name] {
+					return fmt.Errorf("locale %s: message %q has unknown placeholder {%s}", lang, id, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// placeholders returns the names of the placeholders used in a message
+func placeholders(m Message) map[string]bool {
+	names := make(map[string]bool)
+	for _, text := range m.texts() {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			names[match[1]] = true
+		}
+	}
+	return names
+}
+
+// Languages returns the languages the catalog has messages for, sorted
+func (c *Catalog) Languages() []string {
+	var langs []string
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// HasLanguage reports whether the catalog has a locale file for lang
+func (c *Catalog) HasLanguage(lang string) bool {
+	_, exists := c.messages[lang]
+	return exists
+}
+
+// Missing returns the IDs of the default language's messages that lang doesn't translate, sorted
+func (c *Catalog) Missing(lang string) []string {
+	var missing []string
+	for id := range c.messages[DefaultLanguage] {
+		if _, exists := c.messages[lang][id]; !exists {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Has reports whether the catalog has a message with this ID
+func (c *Catalog) Has(id string) bool {
+	_, exists := c.messages[DefaultLanguage][id]
+	return exists
+}
+
+// T returns message id in lang, falling back to the default language and then
+// to the ID itself. args are name/value pairs filling the message's {name}
+// placeholders; a plural message picks its form by the "count" argument.
+//
+//	c.T("hi", "favorites.header", "count", 3)
+func (c *Catalog) T(lang, id string, args ...interface{}) string {
+	message, exists := c.messages[lang][id]
+	if !exists {
+		message, exists = c.messages[DefaultLanguage][id]
+		lang = DefaultLanguage
+	}
+	if !exists {
+		return id
+	}
+
+	values := make(map[string]string, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		values[fmt.Sprint(args[i])] = fmt.Sprint(args[i+1])
+	}
+
+	text := message.Text
+	if message.Plural != nil {
+		text = message.Plural["other"]
+		if count, ok := countArg(args); ok {
+			rule, exists := pluralRules[lang]
+			if !exists {
+				rule = oneOther
+			}
+			if form, exists := message.Plural[rule(count)]; exists {
+				text = form
+			}
+		}
+	}
+
+	return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		if value, exists := values[match[1:len(match)-1]]; exists {
+			return value
+		}
+		return match
+	})
+}
+
+// countArg returns the integer "count" argument
+func countArg(args []interface{}) (int, bool) {
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "count" {
+			count, ok := args[i+1].(int)
+			return count, ok
+		}
+	}
+	return 0, false
+}
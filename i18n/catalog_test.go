@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocalesAreComplete(t *testing.T) {
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lang := range c.Languages() {
+		if missing := c.Missing(lang); len(missing) > 0 {
+			t.Errorf("locale %s is missing %s", lang, strings.Join(missing, ", "))
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lang string
+		id   string
+		args []interface{}
+		want string
+	}{
+		{"en", "mood.prompt", nil, "What's your mood today?"},
+		{"hi", "mood.prompt", nil, "आज आपका मूड कैसा है?"},
+		{"xx", "mood.prompt", nil, "What's your mood today?"},
+		{"hi", "no.such.message", nil, "no.such.message"},
+		{"en", "favorites.added", []interface{}{"id", "abc"}, "Added to favorites! (ID: abc)"},
+		{"en", "photo.credit", []interface{}{"name", "Jane"}, "📷 Photo by Jane on {source}"},
+		{"hi", "favorites.header", []interface{}{"count", 0}, "⭐ **आपके पसंदीदा** (0 सहेजा गया)"},
+		{"hi", "favorites.header", []interface{}{"count", 1}, "⭐ **आपके पसंदीदा** (1 सहेजा गया)"},
+		{"hi", "favorites.header", []interface{}{"count", 2}, "⭐ **आपके पसंदीदा** (2 सहेजे गए)"},
+		{"ta", "favorites.header", []interface{}{"count", 0}, "⭐ **உங்கள் பிடித்தவை** (0 சேமிக்கப்பட்டன)"},
+	}
+
+	for _, tt := range tests {
+		if got := c.T(tt.lang, tt.id, tt.args...); got != tt.want {
+			t.Errorf("T(%s, %s, %v) = %q, want %q", tt.lang, tt.id, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages map[string]map[string]Message
+		err      string
+	}{
+		{"no default", map[string]map[string]Message{"hi": {}}, "en.json is missing"},
+		{"unknown ID", map[string]map[string]Message{
+			"en": {},
+			"hi": {"greeting": {Text: "नमस्ते"}},
+		}, `"greeting" is not in en.json`},
+		{"unknown placeholder", map[string]map[string]Message{
+			"en": {"greeting": {Text: "Hello {name}"}},
+			"hi": {"greeting": {Text: "नमस्ते {nam}"}},
+		}, "unknown placeholder {nam}"},
+	}
+
+	for _, tt := range tests {
+		c := &Catalog{messages: tt.messages}
+		if err := c.validate(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestMessageUnmarshalRequiresOther(t *testing.T) {
+	var m Message
+	if err := m.UnmarshalJSON([]byte(`{"one": "1 item"}`)); err == nil {
+		t.Error("plural message without an other form was accepted")
+	}
+	if err := m.UnmarshalJSON([]byte(`{"one": "1 item", "several": "items"}`)); err == nil {
+		t.Error("unknown plural form was accepted")
+	}
+}
@@ -1,23 +1,63 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/delivery"
+	"github.com/you/moodbot/fakeapis"
 	"github.com/you/moodbot/favorites"
+	"github.com/you/moodbot/fetchers"
 	"github.com/you/moodbot/handlers"
+	"github.com/you/moodbot/history"
+	"github.com/you/moodbot/i18n"
+	"github.com/you/moodbot/media"
+	"github.com/you/moodbot/preferences"
+	"github.com/you/moodbot/prefetch"
+	"github.com/you/moodbot/router"
 	"github.com/you/moodbot/translation"
 	"github.com/you/moodbot/voting"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/webhook"
+	"github.com/you/moodbot/workers"
 )
 
 func main() {
-	token := os.Getenv("TELEGRAM_BOT_TOKEN")
-	if token == "" {
-		log.Fatal("set TELEGRAM_BOT_TOKEN env variable")
+	if len(os.Args) > 1 && os.Args[1] == "corpus" {
+		os.Exit(runCorpusCommand(os.Args[2:]))
 	}
 
-	bot, err := tgbotapi.NewBotAPI(token)
+	configPath := flag.String("config", "", "path to a JSON config file (defaults are used when empty)")
+	mode := flag.String("mode", "", "update delivery mode: polling or webhook (overrides config)")
+	useFakeAPIs := flag.Bool("fake-apis", false, "serve every upstream API, including Telegram, from local fakes")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *mode != "" {
+		cfg.Telegram.Mode = *mode
+	}
+	if *useFakeAPIs {
+		apis := fakeapis.Start()
+		defer apis.Close()
+		apis.Configure(&cfg)
+		log.Printf("Using fake APIs; Telegram calls go to %s", apis.Telegram.URL)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(cfg.Telegram.Token, cfg.Telegram.APIEndpoint)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -26,76 +66,182 @@ func main() {
 
 	// Initialize managers and handlers
 	voteManager := voting.NewVoteManager()
-	favoriteManager := favorites.NewFavoriteManager()
-	translator := translation.NewTranslator()
-	languageManager := translation.NewLanguageManager("user_data")
-	messageHandler := handlers.NewMessageHandler(bot, voteManager, favoriteManager, translator, languageManager)
-
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-
-	updates := bot.GetUpdatesChan(u)
-
-	for update := range updates {
-		if update.Message != nil {
-			if update.Message.IsCommand() {
-				switch update.Message.Command() {
-				case "start":
-					_ = messageHandler.SendMoodKeyboard(update.Message.Chat.ID)
-				case "surprise":
-					messageHandler.HandleSurprise(update.Message.Chat.ID)
-				case "favorites", "favorite":
-					messageHandler.SendFavorites(update.Message.Chat.ID, update.Message.From.ID)
-				case "language", "lang":
-					messageHandler.SendLanguageKeyboard(update.Message.Chat.ID)
-				case "help":
-					messageHandler.SendHelpMessage(update.Message.Chat.ID)
-				default:
-					messageHandler.SendHelpMessage(update.Message.Chat.ID)
-				}
-			}
-			continue
+	favoriteManager := favorites.NewFavoriteManager(cfg.DataDir)
+	translationCache := translation.NewTranslationCache(cfg.DataDir, cfg.Translation.CacheSize)
+	translator := translation.NewTranslator(cfg.Translation, translationCache)
+	languages := translation.NewLanguageRegistry(cfg.Languages)
+	languageManager := translation.NewLanguageManager(cfg.DataDir, languages)
+	catalog, err := i18n.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, lang := range languages.Languages() {
+		if !catalog.HasLanguage(string(lang.Code)) {
+			log.Printf("No i18n/locales/%s.json; %s users get English buttons and messages", lang.Code, lang.Name)
 		}
+	}
+	registry, err := fetchers.NewDefaultRegistry(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deliveryStore := delivery.NewDeliveryStore(cfg.DataDir, cfg.Deliveries)
+	historyStore := history.NewHistoryStore(cfg.DataDir, cfg.History)
+	preferenceManager := preferences.NewPreferenceManager(cfg.DataDir)
+	fileIDStore := media.NewFileIDStore(cfg.DataDir)
+	moodFetcher := fetchers.NewMoodFetcher(registry)
 
-		if update.CallbackQuery != nil {
-			data := update.CallbackQuery.Data
-			chatID := update.CallbackQuery.Message.Chat.ID
-			userID := update.CallbackQuery.From.ID
+	// Keep a few items per mood ready so taps don't wait on the upstream APIs
+	var moods []string
+	for _, mood := range cfg.Moods {
+		moods = append(moods, mood.Name)
+	}
+	prefetcher := prefetch.New(moodFetcher, moods, cfg.Prefetch)
+	prefetcher.Start()
 
-			// Handle voting
-			if voteManager.IsVoteCallback(data) {
-				cb := tgbotapi.NewCallback(update.CallbackQuery.ID, voteManager.HandleVote(data, userID))
-				_, _ = bot.Request(cb)
-				continue
-			}
+	messageHandler := handlers.NewMessageHandler(bot, voteManager, favoriteManager, translator, languageManager, catalog, registry, deliveryStore, historyStore, preferenceManager, fileIDStore, prefetcher, moodFetcher, cfg.Handlers)
+
+	// Translate the labels of moods the catalog doesn't cover before users ask for them
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if n := messageHandler.WarmupTranslations(ctx); n > 0 {
+			log.Printf("Warmed up %d translations", n)
+		}
+	}()
+
+	// Set up routing
+	r := router.New(bot)
+	r.Use(router.Recovery(messageHandler.Localize), router.Logging(), router.RateLimit(cfg.RateLimit.Limit, cfg.RateLimit.Window.Duration, messageHandler.Localize))
+	messageHandler.RegisterRoutes(r)
 
-			// Handle language selection
-			if data == "lang_en" || data == "lang_hi" || data == "lang_ta" {
-				response := messageHandler.HandleLanguageSelection(data, chatID, userID)
-				cb := tgbotapi.NewCallback(update.CallbackQuery.ID, response)
-				_, _ = bot.Request(cb)
-				continue
+	var updates tgbotapi.UpdatesChannel
+	var stopUpdates func(ctx context.Context)
+	switch cfg.Telegram.Mode {
+	case "polling":
+		// Telegram refuses getUpdates while a webhook is set
+		if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			log.Printf("Warning: Could not delete webhook: %v", err)
+		}
+
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = 60
+		updates = bot.GetUpdatesChan(u)
+		stopUpdates = func(ctx context.Context) { bot.StopReceivingUpdates() }
+	case "webhook":
+		tg := cfg.Telegram
+		server := webhook.NewServer(tg.WebhookAddr, tg.WebhookPath, tg.WebhookSecret)
+
+		// The webhook URL is the public URL behind the reverse proxy; leave it unset to post updates locally
+		if tg.WebhookURL != "" {
+			if err := webhook.Register(bot, tg.WebhookURL, tg.WebhookSecret); err != nil {
+				log.Fatalf("could not set webhook: %v", err)
 			}
+		}
 
-			// Handle favorites
-			if favoriteManager.IsFavoriteCallback(data) {
-				originalContent := ""
-				if update.CallbackQuery.Message.Caption != "" {
-					originalContent = update.CallbackQuery.Message.Caption
-				} else {
-					originalContent = update.CallbackQuery.Message.Text
-				}
-				cb := tgbotapi.NewCallback(update.CallbackQuery.ID, messageHandler.HandleFavoriteCallback(data, userID, originalContent))
-				_, _ = bot.Request(cb)
-				continue
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal(err)
+			}
+		}()
+		log.Printf("Listening for webhook updates on %s%s", tg.WebhookAddr, tg.WebhookPath)
+		updates = server.Updates()
+		stopUpdates = func(ctx context.Context) {
+			if err := server.Shutdown(ctx); err != nil {
+				log.Printf("Warning: Webhook server shutdown: %v", err)
 			}
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Process updates in parallel, keeping each chat's updates in order
+	pool := workers.NewPool(cfg.Workers.Count, cfg.Workers.QueueSize, r.Dispatch)
+	stopFeed := make(chan struct{})
+	fed := feedUpdates(updates, pool, stopFeed)
 
-			// Acknowledge callback (remove "loading")
-			cb := tgbotapi.NewCallback(update.CallbackQuery.ID, "Working on it...")
-			_, _ = bot.Request(cb)
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down: waiting for in-flight updates...")
 
-			// Handle mood selection
-			messageHandler.HandleMoodSelection(data, chatID)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Telegram.ShutdownTimeout.Duration)
+	defer cancel()
+
+	stopUpdates(shutdownCtx)
+
+	// Hand buffered updates to the pool before it stops accepting them; webhook
+	// updates were already acknowledged, so Telegram won't resend them
+	close(stopFeed)
+	select {
+	case <-fed:
+	case <-shutdownCtx.Done():
+		log.Printf("Warning: Gave up feeding buffered updates: %v", shutdownCtx.Err())
+	}
+
+	// Draining gets its own budget, so a slow feed can't leave handlers running as state is flushed
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Telegram.ShutdownTimeout.Duration)
+	defer cancelDrain()
+	if err := pool.Shutdown(drainCtx); err != nil {
+		log.Printf("Warning: Gave up waiting for in-flight updates: %v", err)
+	}
+	prefetcher.Stop()
+
+	// Flush state to disk
+	closers := []struct {
+		name   string
+		closer interface{ Close() error }
+	}{
+		{"favorites", favoriteManager},
+		{"languages", languageManager},
+		{"deliveries", deliveryStore},
+		{"history", historyStore},
+		{"preferences", preferenceManager},
+		{"file IDs", fileIDStore},
+		{"translations", translationCache},
+	}
+	for _, c := range closers {
+		if err := c.closer.Close(); err != nil {
+			log.Printf("Error flushing %s: %v", c.name, err)
+		}
+	}
+	log.Println("Shutdown complete")
+}
+
+// feedUpdates submits updates to pool until updates is closed or stop is. Once
+// stopped it only hands over updates already buffered, since a long poll in
+// progress can take a minute to return. The returned channel is closed when
+// feeding has finished.
+func feedUpdates(updates <-chan tgbotapi.Update, pool *workers.Pool, stop <-chan struct{}) <-chan struct{} {
+	submit := func(update tgbotapi.Update) {
+		if err := pool.Submit(update); err != nil {
+			log.Printf("Dropping update %d: %v", update.UpdateID, err)
 		}
 	}
-}
\ No newline at end of file
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				submit(update)
+			case <-stop:
+				for {
+					select {
+					case update, ok := <-updates:
+						if !ok {
+							return
+						}
+						submit(update)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return done
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/workers"
+)
+
+func TestFeedUpdatesStopsDuringIdleLongPoll(t *testing.T) {
+	var mutex sync.Mutex
+	var handled []int
+	pool := workers.NewPool(2, 10, func(u tgbotapi.Update) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		handled = append(handled, u.UpdateID)
+	})
+
+	// A long poll in progress keeps the updates channel open with nothing arriving
+	updates := make(chan tgbotapi.Update, 10)
+	stop := make(chan struct{})
+	fed := feedUpdates(updates, pool, stop)
+	updates <- tgbotapi.Update{UpdateID: 1, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}}}
+
+	close(stop)
+	select {
+	case <-fed:
+	case <-time.After(time.Second):
+		t.Fatal("feeding didn't stop while the long poll was idle")
+	}
+
+	pool.Close()
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(handled) != 1 || handled[0] != 1 {
+		t.Errorf("handled = %v, want the update received before stopping", handled)
+	}
+}
@@ -0,0 +1,185 @@
+package media
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/you/moodbot/storage"
+)
+
+// maxFileIDs is how many file IDs are kept; the least recently used are dropped first
+const maxFileIDs = 5000
+
+// saveEvery is how many changes are made between writes to disk
+const saveEvery = 20
+
+// FileID is a Telegram file_id remembered for an image source
+type FileID struct {
+	Key    string `json:"key"` // the image's URL or local path
+	FileID string `json:"file_id"`
+	UsedAt int64  `json:"used_at"`
+}
+
+// FileIDStore remembers the file_id Telegram assigns to each sent photo, so
+// later sends of the same image don't make Telegram download or receive it again
+type FileIDStore struct {
+	fileIDs map[string]*list.Element // values are FileID
+	order   *list.List               // most recently used first
+	unsaved int
+	mutex   sync.Mutex
+	dataDir string
+}
+
+// NewFileIDStore creates a new file ID store persisted in dataDir
+func NewFileIDStore(dataDir string) *FileIDStore {
+	fs := &FileIDStore{
+		fileIDs: make(map[string]*list.Element),
+		order:   list.New(),
+		dataDir: dataDir,
+	}
+
+	// Create data directory if it doesn't exist
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		fmt.Printf("Warning: Could not create file ID data directory: %v\n", err)
+	}
+
+	fs.loadFileIDs()
+
+	return fs
+}
+
+// Get returns the file ID remembered for key
+func (fs *FileIDStore) Get(key string) (string, bool) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	elem, exists := fs.fileIDs[key]
+	if !exists {
+		return "", false
+	}
+	f := elem.Value.(FileID)
+	f.UsedAt = time.Now().Unix()
+	elem.Value = f
+	fs.order.MoveToFront(elem)
+	return f.FileID, true
+}
+
+// Set remembers the file ID Telegram assigned to the image at key
+func (fs *FileIDStore) Set(key, fileID string) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	f := FileID{Key: key, FileID: fileID, UsedAt: time.Now().Unix()}
+	if elem, exists := fs.fileIDs[key]; exists {
+		elem.Value = f
+		fs.order.MoveToFront(elem)
+	} else {
+		fs.fileIDs[key] = fs.order.PushFront(f)
+	}
+	for len(fs.fileIDs) > maxFileIDs {
+		fs.evictOldest()
+	}
+
+	fs.changed()
+}
+
+// Delete forgets the file ID for key, e.g. when Telegram no longer accepts it
+func (fs *FileIDStore) Delete(key string) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	elem, exists := fs.fileIDs[key]
+	if !exists {
+		return
+	}
+	fs.order.Remove(elem)
+	delete(fs.fileIDs, key)
+
+	fs.changed()
+}
+
+// Close flushes file IDs to disk
+func (fs *FileIDStore) Close() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	return fs.saveFileIDs()
+}
+
+// changed counts a change and saves once enough have built up
+func (fs *FileIDStore) changed() {
+	fs.unsaved++
+	if fs.unsaved >= saveEvery {
+		if err := fs.saveFileIDs(); err != nil {
+			fmt.Printf("Error saving file IDs: %v\n", err)
+		}
+	}
+}
+
+// evictOldest drops the least recently used file ID
+func (fs *FileIDStore) evictOldest() {
+	elem := fs.order.Back()
+	if elem == nil {
+		return
+	}
+	fs.order.Remove(elem)
+	delete(fs.fileIDs, elem.Value.(FileID).Key)
+}
+
+// getFilePath returns the file path for stored file IDs
+func (fs *FileIDStore) getFilePath() string {
+	return filepath.Join(fs.dataDir, "file_ids.json")
+}
+
+// loadFileIDs loads file IDs from file
+func (fs *FileIDStore) loadFileIDs() {
+	data, err := os.ReadFile(fs.getFilePath())
+	if err != nil {
+		// File doesn't exist, that's okay
+		return
+	}
+
+	var fileIDs []FileID
+	if err := json.Unmarshal(data, &fileIDs); err != nil {
+		fmt.Printf("Warning: Could not load file IDs: %v\n", err)
+		return
+	}
+
+	// Oldest first, so each push to the front leaves the newest at the front
+	sort.SliceStable(fileIDs, func(i, j int) bool { return fileIDs[i].UsedAt < fileIDs[j].UsedAt })
+	for _, f := range fileIDs {
+		if elem, exists := fs.fileIDs[f.Key]; exists {
+			fs.order.Remove(elem)
+		}
+		fs.fileIDs[f.Key] = fs.order.PushFront(f)
+	}
+	for len(fs.fileIDs) > maxFileIDs {
+		fs.evictOldest()
+	}
+}
+
+// saveFileIDs saves file IDs to file
+func (fs *FileIDStore) saveFileIDs() error {
+	fileIDs := make([]FileID, 0, len(fs.fileIDs))
+	for elem := fs.order.Back(); elem != nil; elem = elem.Prev() {
+		fileIDs = append(fileIDs, elem.Value.(FileID))
+	}
+
+	data, err := json.MarshalIndent(fileIDs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling file IDs: %v", err)
+	}
+
+	if err := storage.WriteFileAtomic(fs.getFilePath(), data, 0644); err != nil {
+		return fmt.Errorf("error writing file IDs file: %v", err)
+	}
+	fs.unsaved = 0
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestFileIDStorePersists(t *testing.T) {
+	dataDir := t.TempDir()
+	store := NewFileIDStore(dataDir)
+	store.Set("https://images.example.com/a.png", "file-a")
+	store.Set("images/funny/b.jpg", "file-b")
+	store.Delete("images/funny/b.jpg")
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reloaded := NewFileIDStore(dataDir)
+	if fileID, exists := reloaded.Get("https://images.example.com/a.png"); !exists || fileID != "file-a" {
+		t.Errorf("Get() = %q, %v, want file-a", fileID, exists)
+	}
+	if _, exists := reloaded.Get("images/funny/b.jpg"); exists {
+		t.Error("deleted file ID was reloaded")
+	}
+}
+
+func TestFileIDStoreBatchesSaves(t *testing.T) {
+	dataDir := t.TempDir()
+	store := NewFileIDStore(dataDir)
+	for i := 0; i < saveEvery-1; i++ {
+		store.Set(fmt.Sprintf("key-%d", i), "id")
+	}
+	if _, err := os.Stat(store.getFilePath()); !os.IsNotExist(err) {
+		t.Fatalf("file IDs were written before %d changes", saveEvery)
+	}
+
+	store.Set("last", "file-last")
+	if fileID, exists := NewFileIDStore(dataDir).Get("last"); !exists || fileID != "file-last" {
+		t.Errorf("reloaded Get() = %q, %v, want the batch saved", fileID, exists)
+	}
+}
+
+func TestFileIDStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewFileIDStore(t.TempDir())
+	// Fill the store directly; Set would write the file every saveEvery keys
+	for i := 0; i < maxFileIDs; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		store.fileIDs[key] = store.order.PushFront(FileID{Key: key, FileID: "id"})
+	}
+	store.Get("key-0")
+
+	store.Set("new", "id")
+
+	if _, exists := store.fileIDs["key-0"]; !exists {
+		t.Error("recently used key-0 was evicted")
+	}
+	if _, exists := store.fileIDs["key-1"]; exists {
+		t.Error("least recently used key-1 was kept")
+	}
+	if len(store.fileIDs) != maxFileIDs {
+		t.Errorf("len = %d, want %d", len(store.fileIDs), maxFileIDs)
+	}
+}
+
+func TestFileIDStoreReloadKeepsRecencyOrder(t *testing.T) {
+	dataDir := t.TempDir()
+	store := NewFileIDStore(dataDir)
+	store.Set("a", "id-a")
+	store.Set("b", "id-b")
+	store.Get("a")
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reloaded := NewFileIDStore(dataDir)
+	if back := reloaded.order.Back().Value.(FileID).Key; back != "b" {
+		t.Errorf("least recently used after reload = %q, want b", back)
+	}
+}
@@ -6,6 +6,13 @@ type ZenQuote struct {
 	A string `json:"a"`
 }
 
+// DummyJSONQuote represents a quote from DummyJSON
+type DummyJSONQuote struct {
+	ID     int    `json:"id"`
+	Quote  string `json:"quote"`
+	Author string `json:"author"`
+}
+
 // Joke represents a joke from Official Joke API
 type Joke struct {
 	ID        int    `json:"id"`
@@ -16,8 +23,10 @@ type Joke struct {
 
 // Fact represents a fact from Useless Facts API
 type Fact struct {
-	ID   string `json:"id"`
-	Text string `json:"text"`
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	SourceURL string `json:"source_url"`
+	Language  string `json:"language"`
 }
 
 // UnsplashImage represents an image from Unsplash API
@@ -27,30 +36,108 @@ type UnsplashImage struct {
 		Small   string `json:"small"`
 	} `json:"urls"`
 	AltDescription string `json:"alt_description"`
+	User           struct {
+		Name  string `json:"name"`
+		Links struct {
+			HTML string `json:"html"`
+		} `json:"links"`
+	} `json:"user"`
+	Links struct {
+		DownloadLocation string `json:"download_location"`
+	} `json:"links"`
+}
+
+// Image is a picture sent along with content
+type Image struct {
+	URL              string       `json:"url,omitempty"`
+	Path             string       `json:"path,omitempty"` // local file, uploaded instead of URL
+	AltText          string       `json:"alt_text,omitempty"`
+	Credit           *ImageCredit `json:"credit,omitempty"`
+	Provider         string       `json:"provider,omitempty"`          // name of the image provider that found it
+	DownloadLocation string       `json:"download_location,omitempty"` // pinged when the image is shown, as Unsplash requires
+}
+
+// ImageCredit attributes an image to its photographer and source
+type ImageCredit struct {
+	Name       string `json:"name"`
+	ProfileURL string `json:"profile_url,omitempty"`
+	Source     string `json:"source"`               // e.g. "Unsplash"
+	SourceURL  string `json:"source_url,omitempty"` // link to the source's site
 }
 
 // Vote represents a user's vote on content
 type Vote struct {
-	MessageID int
-	UserID    int64
-	Vote      bool // true = thumbs up, false = thumbs down
+	DeliveryID string
+	UserID     int64
+	Vote       bool // true = thumbs up, false = thumbs down
+}
+
+// Delivery records a piece of content sent to a chat so callbacks can refer back to it
+type Delivery struct {
+	ID          string  `json:"id"`
+	ChatID      int64   `json:"chat_id"`
+	Mood        string  `json:"mood"`
+	Content     Content `json:"content"`
+	DeliveredAt int64   `json:"delivered_at"`
 }
 
-// ContentCategory represents different content types with their fetch functions
+// ContentKind identifies the type of content a provider returns
+type ContentKind string
+
+const (
+	KindQuote ContentKind = "quote"
+	KindJoke  ContentKind = "joke"
+	KindFact  ContentKind = "fact"
+)
+
+// Content represents a single piece of content returned by a provider
+type Content struct {
+	Kind      ContentKind `json:"kind"`
+	Body      string      `json:"body,omitempty"`      // quote or fact text
+	Author    string      `json:"author,omitempty"`    // for quotes
+	Setup     string      `json:"setup,omitempty"`     // for jokes
+	Punchline string      `json:"punchline,omitempty"` // for jokes
+	JokeType  string      `json:"joke_type,omitempty"` // for jokes: general, programming, knock-knock...
+	SourceURL string      `json:"source_url,omitempty"`
+	Language  string      `json:"language,omitempty"` // language code of the text; empty means English
+	Image     *Image      `json:"image,omitempty"`
+	Provider  string      `json:"provider"`
+}
+
+// Joke types served by the Official Joke API
+const (
+	JokeGeneral     = "general"
+	JokeProgramming = "programming"
+	JokeKnockKnock  = "knock-knock"
+	JokeDad         = "dad"
+)
+
+// JokeTypes lists the joke types users can choose from
+var JokeTypes = []string{JokeGeneral, JokeProgramming, JokeKnockKnock, JokeDad}
+
+// ContentCategory represents a mood with its button, image query and content and image providers
 type ContentCategory struct {
-	Name       string
-	ImageQuery string
+	Name           string
+	Label          string // button label, translated at runtime
+	Emoji          string
+	ImageQuery     string
+	Providers      []string // names of registered content providers, tried in order
+	ImageProviders []string // names of registered image providers, tried in order
 }
 
 // Favorite represents a user's saved content
 type Favorite struct {
-	ID        string    `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Type      string    `json:"type"` // "quote", "joke", "fact", "image"
-	Content   string    `json:"content"`
-	Author    string    `json:"author,omitempty"`
-	Setup     string    `json:"setup,omitempty"`     // for jokes
-	Punchline string    `json:"punchline,omitempty"` // for jokes
-	ImageURL  string    `json:"image_url,omitempty"` // for images
-	SavedAt   int64     `json:"saved_at"`
-}
\ No newline at end of file
+	ID        string `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Type      string `json:"type"` // "quote", "joke", "fact", "image"
+	Content   string `json:"content"`
+	Author    string `json:"author,omitempty"`
+	Setup     string `json:"setup,omitempty"`     // for jokes
+	Punchline string `json:"punchline,omitempty"` // for jokes
+	ImageURL  string `json:"image_url,omitempty"` // favorites saved before Image was added
+	Image     *Image `json:"image,omitempty"`
+	SourceURL string `json:"source_url,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Language  string `json:"language,omitempty"` // language code of the content; empty means English
+	SavedAt   int64  `json:"saved_at"`
+}
@@ -0,0 +1,108 @@
+package preferences
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/you/moodbot/storage"
+)
+
+// UserPreferences holds a user's content preferences
+type UserPreferences struct {
+	UserID   int64  `json:"user_id"`
+	JokeType string `json:"joke_type,omitempty"` // empty means any type
+}
+
+// PreferenceManager manages per-user content preferences
+type PreferenceManager struct {
+	preferences map[int64]UserPreferences
+	mutex       sync.RWMutex
+	dataDir     string
+}
+
+// NewPreferenceManager creates a new preference manager
+func NewPreferenceManager(dataDir string) *PreferenceManager {
+	pm := &PreferenceManager{
+		preferences: make(map[int64]UserPreferences),
+		dataDir:     dataDir,
+	}
+
+	// Create data directory if it doesn't exist
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		fmt.Printf("Warning: Could not create preferences data directory: %v\n", err)
+	}
+
+	pm.loadPreferences()
+
+	return pm
+}
+
+// GetJokeType returns the user's preferred joke type, or "" for any type
+func (pm *PreferenceManager) GetJokeType(userID int64) string {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return pm.preferences[userID].JokeType
+}
+
+// SetJokeType sets the user's preferred joke type; "" clears it
+func (pm *PreferenceManager) SetJokeType(userID int64, jokeType string) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	prefs := pm.preferences[userID]
+	prefs.UserID = userID
+	prefs.JokeType = jokeType
+	pm.preferences[userID] = prefs
+	return pm.savePreferences()
+}
+
+// Close flushes preferences to disk
+func (pm *PreferenceManager) Close() error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	return pm.savePreferences()
+}
+
+// getFilePath returns the file path for stored preferences
+func (pm *PreferenceManager) getFilePath() string {
+	return filepath.Join(pm.dataDir, "preferences.json")
+}
+
+// loadPreferences loads user preferences from file
+func (pm *PreferenceManager) loadPreferences() {
+	data, err := os.ReadFile(pm.getFilePath())
+	if err != nil {
+		// File doesn't exist, that's okay
+		return
+	}
+
+	var prefs []UserPreferences
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		fmt.Printf("Warning: Could not load preferences: %v\n", err)
+		return
+	}
+
+	for _, p := range prefs {
+		pm.preferences[p.UserID] = p
+	}
+}
+
+// savePreferences saves user preferences to file
+func (pm *PreferenceManager) savePreferences() error {
+	prefs := make([]UserPreferences, 0, len(pm.preferences))
+	for _, p := range pm.preferences {
+		prefs = append(prefs, p)
+	}
+
+	data, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling preferences: %v", err)
+	}
+
+	return storage.WriteFileAtomic(pm.getFilePath(), data, 0644)
+}
@@ -0,0 +1,124 @@
+package prefetch
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+)
+
+// Fetcher fetches a complete item (content plus image) for a mood
+type Fetcher interface {
+	FetchMood(ctx context.Context, mood string) (models.Content, error)
+	FetchJoke(ctx context.Context, mood, jokeType string) (models.Content, error)
+	FetchNative(ctx context.Context, mood, lang string) (models.Content, error)
+	AddImage(ctx context.Context, mood string, content models.Content) models.Content
+}
+
+// Prefetcher keeps a small buffer of ready items for each mood and refills it in the background.
+// When a mood's buffer is empty, for example because its provider is slow, it fetches live.
+type Prefetcher struct {
+	fetcher Fetcher
+	cfg     config.PrefetchConfig
+	buffers map[string]chan models.Content
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New creates a prefetcher for the given moods; call Start to begin filling buffers
+func New(fetcher Fetcher, moods []string, cfg config.PrefetchConfig) *Prefetcher {
+	p := &Prefetcher{
+		fetcher: fetcher,
+		cfg:     cfg,
+		buffers: make(map[string]chan models.Content),
+	}
+	if cfg.Enabled {
+		for _, mood := range moods {
+			p.buffers[mood] = make(chan models.Content, cfg.BufferSize)
+		}
+	}
+	return p
+}
+
+// Start starts one background refill loop per mood
+func (p *Prefetcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	for mood, buffer := range p.buffers {
+		p.wg.Add(1)
+		go p.refill(ctx, mood, buffer)
+	}
+}
+
+// Stop stops the refill loops and waits for in-flight fetches to finish
+func (p *Prefetcher) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+// FetchMood returns a buffered item for the mood, or fetches one live if none is ready
+func (p *Prefetcher) FetchMood(ctx context.Context, mood string) (models.Content, error) {
+	if buffer, exists := p.buffers[mood]; exists {
+		select {
+		case content := <-buffer:
+			return content, nil
+		default:
+		}
+	}
+	return p.fetcher.FetchMood(ctx, mood)
+}
+
+// FetchJoke fetches a joke of the given type live; typed jokes are not buffered
+func (p *Prefetcher) FetchJoke(ctx context.Context, mood, jokeType string) (models.Content, error) {
+	return p.fetcher.FetchJoke(ctx, mood, jokeType)
+}
+
+// FetchNative fetches content in a language other than English live; it is not buffered
+func (p *Prefetcher) FetchNative(ctx context.Context, mood, lang string) (models.Content, error) {
+	return p.fetcher.FetchNative(ctx, mood, lang)
+}
+
+// AddImage adds an image to content fetched without one; buffered items already have theirs
+func (p *Prefetcher) AddImage(ctx context.Context, mood string, content models.Content) models.Content {
+	return p.fetcher.AddImage(ctx, mood, content)
+}
+
+// Buffered returns how many items are ready for a mood
+func (p *Prefetcher) Buffered(mood string) int {
+	return len(p.buffers[mood])
+}
+
+// refill fetches one item per refill interval while the mood's buffer has room
+func (p *Prefetcher) refill(ctx context.Context, mood string, buffer chan models.Content) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.RefillInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		if len(buffer) < cap(buffer) {
+			fetchCtx, cancel := context.WithTimeout(ctx, p.cfg.FetchTimeout.Duration)
+			content, err := p.fetcher.FetchMood(fetchCtx, mood)
+			cancel()
+
+			// Only this loop adds to the buffer, so there is still room
+			if err == nil {
+				buffer <- content
+			} else if ctx.Err() == nil {
+				log.Printf("prefetch %s: %v", mood, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package prefetch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/models"
+)
+
+// countingFetcher numbers the items it returns and can be made to block
+type countingFetcher struct {
+	calls   int
+	blocked chan struct{} // when set, fetches wait until it is closed
+	mutex   sync.Mutex
+}
+
+func (f *countingFetcher) FetchJoke(ctx context.Context, mood, jokeType string) (models.Content, error) {
+	return f.FetchMood(ctx, mood)
+}
+
+func (f *countingFetcher) FetchNative(ctx context.Context, mood, lang string) (models.Content, error) {
+	return f.FetchMood(ctx, mood)
+}
+
+func (f *countingFetcher) AddImage(ctx context.Context, mood string, content models.Content) models.Content {
+	return content
+}
+
+func (f *countingFetcher) FetchMood(ctx context.Context, mood string) (models.Content, error) {
+	f.mutex.Lock()
+	f.calls++
+	n := f.calls
+	blocked := f.blocked
+	f.mutex.Unlock()
+
+	if blocked != nil {
+		select {
+		case <-blocked:
+		case <-ctx.Done():
+			return models.Content{}, ctx.Err()
+		}
+	}
+	return models.Content{Kind: models.KindFact, Body: mood + string(rune('0'+n))}, nil
+}
+
+func testConfig() config.PrefetchConfig {
+	return config.PrefetchConfig{
+		Enabled:        true,
+		BufferSize:     2,
+		RefillInterval: config.Duration{Duration: time.Millisecond},
+		FetchTimeout:   config.Duration{Duration: time.Second},
+	}
+}
+
+// waitFor polls until cond is true or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPrefetcherServesFromBuffer(t *testing.T) {
+	fetcher := &countingFetcher{}
+	p := New(fetcher, []string{"funny"}, testConfig())
+	p.Start()
+	defer p.Stop()
+
+	waitFor(t, func() bool { return p.Buffered("funny") == 2 })
+
+	content, err := p.FetchMood(context.Background(), "funny")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Body != "funny1" {
+		t.Errorf("body = %q, want the first prefetched item", content.Body)
+	}
+
+	// The buffer is topped up again in the background
+	waitFor(t, func() bool { return p.Buffered("funny") == 2 })
+}
+
+func TestPrefetcherFallsBackToLiveFetch(t *testing.T) {
+	fetcher := &countingFetcher{blocked: make(chan struct{})}
+	p := New(fetcher, []string{"funny"}, testConfig())
+	p.Start()
+
+	// The refill loop is stuck on a slow provider, so the buffer stays empty
+	waitFor(t, func() bool {
+		fetcher.mutex.Lock()
+		defer fetcher.mutex.Unlock()
+		return fetcher.calls == 1
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.FetchMood(ctx, "funny"); err == nil {
+		t.Error("expected the live fetch to hit the deadline")
+	}
+	fetcher.mutex.Lock()
+	calls := fetcher.calls
+	fetcher.mutex.Unlock()
+	if calls != 2 {
+		t.Errorf("fetcher called %d times, want a live fetch besides the refill", calls)
+	}
+
+	close(fetcher.blocked)
+	p.Stop()
+}
+
+func TestPrefetcherDisabled(t *testing.T) {
+	fetcher := &countingFetcher{}
+	cfg := testConfig()
+	cfg.Enabled = false
+	p := New(fetcher, []string{"funny"}, cfg)
+	p.Start()
+	defer p.Stop()
+
+	content, err := p.FetchMood(context.Background(), "funny")
+	if err != nil || content.Body != "funny1" {
+		t.Errorf("content = %+v, err = %v; want a live fetch", content, err)
+	}
+}
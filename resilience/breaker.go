@@ -0,0 +1,120 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling an upstream whose circuit is open
+var ErrCircuitOpen = errors.New("circuit open")
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = iota
+	// Open rejects calls until the cooldown has passed
+	Open
+	// HalfOpen lets a single trial call through after the cooldown
+	HalfOpen
+)
+
+// String returns the state name
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops calling a failing upstream for a cooldown period.
+// After FailureThreshold consecutive failures it opens; once the cooldown has
+// passed a single trial call is allowed, which closes it again on success.
+type CircuitBreaker struct {
+	failureThreshold int
+	cooldown         time.Duration
+	state            State
+	failures         int
+	openedAt         time.Time
+	trialInFlight    bool
+	mutex            sync.Mutex
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{failureThreshold: failureThreshold, cooldown: cooldown}
+}
+
+// Allow returns ErrCircuitOpen if the call should not be made
+func (cb *CircuitBreaker) Allow() error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	switch cb.currentState() {
+	case Open:
+		return ErrCircuitOpen
+	case HalfOpen:
+		if cb.trialInFlight {
+			return ErrCircuitOpen
+		}
+		cb.trialInFlight = true
+	}
+	return nil
+}
+
+// Success records a successful call and closes the circuit
+func (cb *CircuitBreaker) Success() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.state = Closed
+	cb.failures = 0
+	cb.trialInFlight = false
+}
+
+// Failure records a failed call, opening the circuit once the threshold is reached
+func (cb *CircuitBreaker) Failure() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.failures++
+	if cb.currentState() == HalfOpen || cb.failures >= cb.failureThreshold {
+		cb.state = Open
+		cb.openedAt = time.Now()
+	}
+	cb.trialInFlight = false
+}
+
+// Release ends a call that neither succeeded nor failed, such as one the
+// caller cancelled, so a half-open circuit allows another trial
+func (cb *CircuitBreaker) Release() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.trialInFlight = false
+}
+
+// State returns the current state
+func (cb *CircuitBreaker) State() State {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	return cb.currentState()
+}
+
+// currentState moves an open circuit to half-open once the cooldown has passed
+func (cb *CircuitBreaker) currentState() State {
+	if cb.state == Open && time.Since(cb.openedAt) >= cb.cooldown {
+		cb.state = HalfOpen
+	}
+	return cb.state
+}
@@ -0,0 +1,135 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var fastPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), fastPolicy, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("err = %v after %d calls, want success on the third", err, calls)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), fastPolicy, func(ctx context.Context) error {
+		calls++
+		return errors.New("down")
+	})
+	if err == nil || calls != fastPolicy.MaxAttempts {
+		t.Errorf("err = %v after %d calls, want failure after %d", err, calls, fastPolicy.MaxAttempts)
+	}
+}
+
+func TestRetryStopsOnPermanent(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), fastPolicy, func(ctx context.Context) error {
+		calls++
+		return Permanent(errors.New("not found"))
+	})
+	if !IsPermanent(err) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want one permanent failure", err, calls)
+	}
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	slow := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: time.Second}
+
+	start := time.Now()
+	Retry(ctx, slow, func(ctx context.Context) error { return errors.New("down") })
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("retry took %s, want it to stop at the deadline", elapsed)
+	}
+}
+
+func TestRetryReturnsContextErrorDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	err := Retry(ctx, slow, func(ctx context.Context) error {
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errors.New("down")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestBackoffStaysWithinBounds(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for attempt := 1; attempt < 10; attempt++ {
+		for i := 0; i < 50; i++ {
+			if d := backoff(policy, attempt); d < 0 || d > policy.MaxDelay {
+				t.Fatalf("backoff(%d) = %s, want within [0, %s]", attempt, d, policy.MaxDelay)
+			}
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(2, 20*time.Millisecond)
+
+	cb.Failure()
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("closed circuit rejected a call: %v", err)
+	}
+	cb.Failure()
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() = %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if cb.State() != HalfOpen {
+		t.Fatalf("state = %s, want half-open after the cooldown", cb.State())
+	}
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("half-open circuit rejected the trial call: %v", err)
+	}
+	if err := cb.Allow(); err == nil {
+		t.Fatal("half-open circuit allowed a second concurrent trial")
+	}
+
+	cb.Failure()
+	if cb.State() != Open {
+		t.Fatalf("state = %s, want open after a failed trial", cb.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	cb.Allow()
+	cb.Success()
+	if cb.State() != Closed {
+		t.Errorf("state = %s, want closed after a successful trial", cb.State())
+	}
+}
+
+func TestCircuitBreakerRelease(t *testing.T) {
+	cb := NewCircuitBreaker(1, 10*time.Millisecond)
+	cb.Failure()
+	time.Sleep(20 * time.Millisecond)
+
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("half-open circuit rejected the trial call: %v", err)
+	}
+	cb.Release()
+	if cb.State() != HalfOpen {
+		t.Fatalf("state = %s, want half-open after a released trial", cb.State())
+	}
+	if err := cb.Allow(); err != nil {
+		t.Errorf("released trial blocked the next one: %v", err)
+	}
+}
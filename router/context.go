@@ -0,0 +1,87 @@
+package router
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Context carries a single update through the middleware chain to its handler
+type Context struct {
+	Update  tgbotapi.Update
+	ChatID  int64
+	UserID  int64
+	Command string // command name without the slash, for command messages
+	Args    string // text after the command
+	Data    string // callback data, for callback queries
+
+	LanguageCode string // the sender's Telegram app language, e.g. "hi" or "pt-br"; may be empty
+
+	requester Requester
+	answered  bool
+}
+
+// newContext extracts the common fields from an update
+func newContext(update tgbotapi.Update, requester Requester) *Context {
+	c := &Context{Update: update, requester: requester}
+
+	switch {
+	case update.Message != nil:
+		c.ChatID = update.Message.Chat.ID
+		if update.Message.From != nil {
+			c.UserID = update.Message.From.ID
+			c.LanguageCode = update.Message.From.LanguageCode
+		}
+		if update.Message.IsCommand() {
+			c.Command = update.Message.Command()
+			c.Args = update.Message.CommandArguments()
+		}
+	case update.CallbackQuery != nil:
+		c.Data = update.CallbackQuery.Data
+		c.UserID = update.CallbackQuery.From.ID
+		c.LanguageCode = update.CallbackQuery.From.LanguageCode
+		if update.CallbackQuery.Message != nil {
+			c.ChatID = update.CallbackQuery.Message.Chat.ID
+		}
+	}
+
+	return c
+}
+
+// IsCallback reports whether the update is a callback query
+func (c *Context) IsCallback() bool {
+	return c.Update.CallbackQuery != nil
+}
+
+// IsPrivate reports whether the update comes from a private chat with the bot
+func (c *Context) IsPrivate() bool {
+	switch {
+	case c.Update.Message != nil:
+		return c.Update.Message.Chat.IsPrivate()
+	case c.Update.CallbackQuery != nil && c.Update.CallbackQuery.Message != nil:
+		return c.Update.CallbackQuery.Message.Chat.IsPrivate()
+	}
+	return false
+}
+
+// Answer answers the callback query with a short notification. It does nothing for other updates.
+// Only the first answer is sent; the router answers unanswered callbacks after the handler returns.
+func (c *Context) Answer(text string) {
+	if c.IsCallback() {
+		c.answer(tgbotapi.NewCallback(c.Update.CallbackQuery.ID, text))
+	}
+}
+
+// AnswerAlert answers the callback query with an alert the user has to dismiss
+func (c *Context) AnswerAlert(text string) {
+	if c.IsCallback() {
+		c.answer(tgbotapi.NewCallbackWithAlert(c.Update.CallbackQuery.ID, text))
+	}
+}
+
+// answer sends a callback answer once
+func (c *Context) answer(cb tgbotapi.CallbackConfig) {
+	if c.answered {
+		return
+	}
+	c.answered = true
+	_, _ = c.requester.Request(cb)
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Message IDs the middleware looks up with its Localizer
+const (
+	PanicMessage       = "router.panic"
+	RateLimitedMessage = "router.rate_limited"
+	ForbiddenMessage   = "router.forbidden"
+)
+
+// Localizer returns the text of a message ID in the language of the update's user
+type Localizer func(c *Context, id string) string
+
+// Logging logs every routed update and how long it took to handle
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			start := time.Now()
+			next(c)
+
+			switch {
+			case c.Command != "":
+				log.Printf("command /%s from user %d in chat %d (%s)", c.Command, c.UserID, c.ChatID, time.Since(start))
+			case c.IsCallback():
+				log.Printf("callback %q from user %d in chat %d (%s)", c.Data, c.UserID, c.ChatID, time.Since(start))
+			default:
+				log.Printf("message from user %d in chat %d (%s)", c.UserID, c.ChatID, time.Since(start))
+			}
+		}
+	}
+}
+
+// Recovery stops a panicking handler from taking down the bot
+func Recovery(localize Localizer) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("panic handling update %d: %v\n%s", c.Update.UpdateID, r, debug.Stack())
+					c.Answer(localize(c, PanicMessage))
+				}
+			}()
+			next(c)
+		}
+	}
+}
+
+// RateLimit allows each user at most limit updates per window; extra updates are dropped
+func RateLimit(limit int, window time.Duration, localize Localizer) Middleware {
+	type bucket struct {
+		start time.Time
+		count int
+	}
+	buckets := make(map[int64]*bucket)
+	var mutex sync.Mutex
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			now := time.Now()
+
+			mutex.Lock()
+			b, exists := buckets[c.UserID]
+			if !exists || now.Sub(b.start) >= window {
+				b = &bucket{start: now}
+				buckets[c.UserID] = b
+			}
+			b.count++
+			allowed := b.count <= limit
+
+			// Forget idle users so the map doesn't grow forever
+			if len(buckets) > 10000 {
+				for id, old := range buckets {
+					if now.Sub(old.start) >= window {
+						delete(buckets, id)
+					}
+				}
+			}
+			mutex.Unlock()
+
+			if !allowed {
+				c.Answer(localize(c, RateLimitedMessage))
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// Auth only lets updates through from users that allowed accepts
+func Auth(allowed func(userID int64) bool, localize Localizer) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if !allowed(c.UserID) {
+				c.Answer(localize(c, ForbiddenMessage))
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// AllowUsers returns an Auth check that accepts only the given user IDs
+func AllowUsers(userIDs ...int64) func(userID int64) bool {
+	allowed := make(map[int64]bool, len(userIDs))
+	for _, id := range userIDs {
+		allowed[id] = true
+	}
+	return func(userID int64) bool {
+		return allowed[userID]
+	}
+}
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandlerFunc handles a routed update
+type HandlerFunc func(c *Context)
+
+// Middleware wraps a handler with extra behaviour
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Requester is the part of the Telegram client the router needs to answer callbacks
+type Requester interface {
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// prefixRoute is a callback handler registered for a data prefix
+type prefixRoute struct {
+	prefix  string
+	handler HandlerFunc
+}
+
+// Router dispatches updates to handlers registered for commands, callbacks and plain text
+type Router struct {
+	requester       Requester
+	middleware      []Middleware
+	commands        map[string]HandlerFunc
+	callbacks       map[string]HandlerFunc
+	prefixes        []prefixRoute
+	text            HandlerFunc
+	defaultCommand  HandlerFunc
+	defaultCallback HandlerFunc
+}
+
+// New creates a router that answers callbacks through requester
+func New(requester Requester) *Router {
+	return &Router{
+		requester: requester,
+		commands:  make(map[string]HandlerFunc),
+		callbacks: make(map[string]HandlerFunc),
+	}
+}
+
+// Use appends middleware that runs for every update, in the order given
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Command registers a handler for a command such as "start"
+func (r *Router) Command(name string, h HandlerFunc, middleware ...Middleware) {
+	r.commands[name] = chain(h, middleware)
+}
+
+// Callback registers a handler for callback data that matches exactly
+func (r *Router) Callback(data string, h HandlerFunc, middleware ...Middleware) {
+	r.callbacks[data] = chain(h, middleware)
+}
+
+// CallbackPrefix registers a handler for callback data starting with prefix.
+// When several prefixes match, the longest one wins.
+func (r *Router) CallbackPrefix(prefix string, h HandlerFunc, middleware ...Middleware) {
+	r.prefixes = append(r.prefixes, prefixRoute{prefix: prefix, handler: chain(h, middleware)})
+	sort.SliceStable(r.prefixes, func(i, j int) bool {
+		return len(r.prefixes[i].prefix) > len(r.prefixes[j].prefix)
+	})
+}
+
+// Text registers a handler for messages that aren't commands
+func (r *Router) Text(h HandlerFunc, middleware ...Middleware) {
+	r.text = chain(h, middleware)
+}
+
+// DefaultCommand registers a handler for unknown commands
+func (r *Router) DefaultCommand(h HandlerFunc, middleware ...Middleware) {
+	r.defaultCommand = chain(h, middleware)
+}
+
+// DefaultCallback registers a handler for callback data no other route matches
+func (r *Router) DefaultCallback(h HandlerFunc, middleware ...Middleware) {
+	r.defaultCallback = chain(h, middleware)
+}
+
+// Dispatch routes a single update through the middleware chain to its handler
+func (r *Router) Dispatch(update tgbotapi.Update) {
+	c := newContext(update, r.requester)
+
+	h := r.route(c)
+	if h != nil {
+		chain(h, r.middleware)(c)
+	}
+
+	// Always stop the loading spinner on the button
+	if c.IsCallback() {
+		c.Answer("")
+	}
+}
+
+// route finds the handler for an update, or nil if nothing should handle it
+func (r *Router) route(c *Context) HandlerFunc {
+	switch {
+	case c.Update.Message != nil:
+		if c.Update.Message.IsCommand() {
+			if h, exists := r.commands[c.Command]; exists {
+				return h
+			}
+			return r.defaultCommand
+		}
+		return r.text
+	case c.IsCallback():
+		if h, exists := r.callbacks[c.Data]; exists {
+			return h
+		}
+		for _, route := range r.prefixes {
+			if strings.HasPrefix(c.Data, route.prefix) {
+				return route.handler
+			}
+		}
+		return r.defaultCallback
+	}
+	return nil
+}
+
+// chain wraps h so the first middleware is the outermost
+func chain(h HandlerFunc, middleware []Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
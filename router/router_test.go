@@ -0,0 +1,209 @@
+package router
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/telegramtest"
+)
+
+func command(userID int64, text string) tgbotapi.Update {
+	length := len(text)
+	if i := strings.IndexByte(text, ' '); i >= 0 {
+		length = i
+	}
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:     text,
+		From:     &tgbotapi.User{ID: userID},
+		Chat:     &tgbotapi.Chat{ID: userID, Type: "private"},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}},
+	}}
+}
+
+func callback(userID int64, data string) tgbotapi.Update {
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "cb-" + data,
+		From:    &tgbotapi.User{ID: userID},
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: userID, Type: "private"}},
+		Data:    data,
+	}}
+}
+
+// messageIDs is a Localizer that returns the message ID itself
+func messageIDs(c *Context, id string) string { return id }
+
+// answers returns the text of every callback answer recorded
+func answers(recorder *telegramtest.Recorder) []string {
+	var result []string
+	for _, cb := range recorder.Callbacks() {
+		result = append(result, cb.Text)
+	}
+	return result
+}
+
+func TestDispatchCommands(t *testing.T) {
+	r := New(telegramtest.NewRecorder())
+	var got []string
+	r.Command("joke", func(c *Context) { got = append(got, "joke:"+c.Args) })
+	r.DefaultCommand(func(c *Context) { got = append(got, "default:"+c.Command) })
+	r.Text(func(c *Context) { got = append(got, "text:"+c.Update.Message.Text) })
+
+	r.Dispatch(command(1, "/joke dad"))
+	r.Dispatch(command(1, "/nope"))
+	plain := command(1, "hello")
+	plain.Message.Entities = nil
+	r.Dispatch(plain)
+
+	want := []string{"joke:dad", "default:nope", "text:hello"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handled %v, want %v", got, want)
+	}
+}
+
+func TestDispatchCallbacks(t *testing.T) {
+	recorder := telegramtest.NewRecorder()
+	r := New(recorder)
+	var got []string
+	handler := func(name string) HandlerFunc {
+		return func(c *Context) { got = append(got, name+":"+c.Data) }
+	}
+	r.Callback("funny", handler("exact"))
+	r.CallbackPrefix("lang_", handler("lang"))
+	r.CallbackPrefix("langdetect_", handler("langdetect"))
+	r.CallbackPrefix("l", handler("l"))
+	r.DefaultCallback(handler("default"))
+
+	for _, data := range []string{"funny", "lang_hi", "langdetect_keep", "lx", "other"} {
+		r.Dispatch(callback(1, data))
+	}
+
+	// Exact matches win, then the longest prefix, regardless of registration order
+	want := []string{"exact:funny", "lang:lang_hi", "langdetect:langdetect_keep", "l:lx", "default:other"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handled %v, want %v", got, want)
+	}
+	// The router stops the loading spinner on every button
+	if callbacks := recorder.Callbacks(); len(callbacks) != len(want) {
+		t.Errorf("got %d callback answers, want %d", len(callbacks), len(want))
+	}
+}
+
+func TestDispatchUnroutedUpdate(t *testing.T) {
+	recorder := telegramtest.NewRecorder()
+	r := New(recorder)
+	called := false
+	r.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) { called = true; next(c) }
+	})
+
+	// No text handler and no default callback: nothing runs, but the button is still answered
+	plain := command(1, "hello")
+	plain.Message.Entities = nil
+	r.Dispatch(plain)
+	r.Dispatch(callback(1, "anything"))
+
+	if called {
+		t.Error("middleware ran for an update with no handler")
+	}
+	if got := answers(recorder); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("answers = %q, want one empty answer", got)
+	}
+}
+
+func TestAnswerOnce(t *testing.T) {
+	recorder := telegramtest.NewRecorder()
+	r := New(recorder)
+	r.Callback("vote", func(c *Context) {
+		c.Answer("first")
+		c.AnswerAlert("second")
+	})
+
+	r.Dispatch(callback(1, "vote"))
+	if got := answers(recorder); !reflect.DeepEqual(got, []string{"first"}) {
+		t.Errorf("answers = %q, want only the first", got)
+	}
+
+	// Answering a message does nothing
+	recorder.Reset()
+	r.Command("start", func(c *Context) { c.Answer("ignored") })
+	r.Dispatch(command(1, "/start"))
+	if sent := recorder.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d requests for a message, want none", len(sent))
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	r := New(telegramtest.NewRecorder())
+	var got []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) {
+				got = append(got, name+">")
+				next(c)
+				got = append(got, "<"+name)
+			}
+		}
+	}
+	r.Use(trace("a"), trace("b"))
+	r.Command("start", func(c *Context) { got = append(got, "handler") }, trace("route"))
+
+	r.Dispatch(command(1, "/start"))
+
+	// Global middleware runs first, in the order given, then the route's own
+	want := []string{"a>", "b>", "route>", "handler", "<route", "<b", "<a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	recorder := telegramtest.NewRecorder()
+	r := New(recorder)
+	r.Use(Recovery(messageIDs))
+	r.Callback("boom", func(c *Context) { panic("boom") })
+
+	r.Dispatch(callback(1, "boom"))
+	if got := answers(recorder); !reflect.DeepEqual(got, []string{PanicMessage}) {
+		t.Errorf("answers = %q", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	recorder := telegramtest.NewRecorder()
+	r := New(recorder)
+	r.Use(RateLimit(2, time.Hour, messageIDs))
+	handled := 0
+	r.Callback("tap", func(c *Context) { handled++ })
+
+	for i := 0; i < 3; i++ {
+		r.Dispatch(callback(1, "tap"))
+	}
+	r.Dispatch(callback(2, "tap"))
+
+	if handled != 3 {
+		t.Errorf("handled %d taps, want 2 from user 1 and 1 from user 2", handled)
+	}
+	if got := answers(recorder); got[2] != RateLimitedMessage {
+		t.Errorf("answers = %q", got)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	recorder := telegramtest.NewRecorder()
+	r := New(recorder)
+	handled := 0
+	r.Callback("admin", func(c *Context) { handled++ }, Auth(AllowUsers(1), messageIDs))
+
+	r.Dispatch(callback(1, "admin"))
+	r.Dispatch(callback(2, "admin"))
+
+	if handled != 1 {
+		t.Errorf("handled %d, want only the allowed user", handled)
+	}
+	if got := answers(recorder); !reflect.DeepEqual(got, []string{"", ForbiddenMessage}) {
+		t.Errorf("answers = %q", got)
+	}
+}
@@ -0,0 +1,134 @@
+package telegramtest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrSendFailed is returned for sends the recorder is told to fail
+var ErrSendFailed = errors.New("telegramtest: send failed")
+
+// Recorder is a fake Telegram client that records everything sent through it
+type Recorder struct {
+	// FailPhotos makes every photo send fail, to exercise text fallbacks
+	FailPhotos bool
+	// RejectFileIDs makes photo sends by file ID fail, as when Telegram no longer has the file
+	RejectFileIDs bool
+
+	sent   []tgbotapi.Chattable
+	nextID int
+	mutex  sync.Mutex
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{nextID: 1}
+}
+
+// Send records c and returns a message with the next message ID.
+// Sent photos get a file ID of "photo-{message ID}".
+func (r *Recorder) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.sent = append(r.sent, c)
+	photo, isPhoto := c.(tgbotapi.PhotoConfig)
+	if isPhoto && r.FailPhotos {
+		return tgbotapi.Message{}, ErrSendFailed
+	}
+	if _, byFileID := photo.File.(tgbotapi.FileID); isPhoto && byFileID && r.RejectFileIDs {
+		return tgbotapi.Message{}, ErrSendFailed
+	}
+
+	msg := tgbotapi.Message{MessageID: r.nextID}
+	if isPhoto {
+		msg.Photo = []tgbotapi.PhotoSize{{FileID: fmt.Sprintf("photo-%d", r.nextID)}}
+	}
+	r.nextID++
+	return msg, nil
+}
+
+// Request records c and returns a successful response
+func (r *Recorder) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.sent = append(r.sent, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// Sent returns everything sent or requested, in order
+func (r *Recorder) Sent() []tgbotapi.Chattable {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := make([]tgbotapi.Chattable, len(r.sent))
+	copy(result, r.sent)
+	return result
+}
+
+// Messages returns the text messages that were sent, in order
+func (r *Recorder) Messages() []tgbotapi.MessageConfig {
+	var result []tgbotapi.MessageConfig
+	for _, c := range r.Sent() {
+		if msg, ok := c.(tgbotapi.MessageConfig); ok {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Photos returns the photos that were sent (including failed ones), in order
+func (r *Recorder) Photos() []tgbotapi.PhotoConfig {
+	var result []tgbotapi.PhotoConfig
+	for _, c := range r.Sent() {
+		if photo, ok := c.(tgbotapi.PhotoConfig); ok {
+			result = append(result, photo)
+		}
+	}
+	return result
+}
+
+// Callbacks returns the callback query answers that were requested, in order
+func (r *Recorder) Callbacks() []tgbotapi.CallbackConfig {
+	var result []tgbotapi.CallbackConfig
+	for _, c := range r.Sent() {
+		if cb, ok := c.(tgbotapi.CallbackConfig); ok {
+			result = append(result, cb)
+		}
+	}
+	return result
+}
+
+// Reset forgets everything recorded so far
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.sent = nil
+}
+
+// Buttons flattens an inline keyboard into "text=data" pairs, row by row
+func Buttons(markup interface{}) [][]string {
+	keyboard, ok := markup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok {
+		return nil
+	}
+
+	var rows [][]string
+	for _, row := range keyboard.InlineKeyboard {
+		var buttons []string
+		for _, button := range row {
+			data := ""
+			if button.CallbackData != nil {
+				data = *button.CallbackData
+			}
+			buttons = append(buttons, button.Text+"="+data)
+		}
+		rows = append(rows, buttons)
+	}
+	return rows
+}
@@ -0,0 +1,99 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/you/moodbot/config"
+)
+
+// Backend names, as used in translation.backend and translation.fallbacks
+const (
+	MyMemoryName       = "mymemory"
+	LibreTranslateName = "libretranslate"
+	NoopName           = "none"
+)
+
+// ErrQuotaExceeded is returned for a backend that has used up its daily quota
+var ErrQuotaExceeded = errors.New("daily quota exceeded")
+
+// TranslationBackend translates text from one language to another with one translation engine
+type TranslationBackend interface {
+	Name() string
+	Translate(ctx context.Context, text string, source, target Language) (string, error)
+}
+
+// NewBackend creates the backend called name from the translation config
+func NewBackend(name string, cfg config.TranslationConfig) (TranslationBackend, error) {
+	switch name {
+	case MyMemoryName:
+		return NewMyMemoryBackend(cfg.MyMemory), nil
+	case LibreTranslateName:
+		return NewLibreTranslateBackend(cfg.LibreTranslate), nil
+	case NoopName:
+		return NoopBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown translation backend %q", name)
+	}
+}
+
+// NoopBackend leaves text untranslated. As the primary backend it turns
+// machine translation off; as a fallback it ends the chain quietly.
+type NoopBackend struct{}
+
+// Name returns the name the backend is configured under
+func (NoopBackend) Name() string { return NoopName }
+
+// Translate returns text unchanged
+func (NoopBackend) Translate(ctx context.Context, text string, source, target Language) (string, error) {
+	return text, nil
+}
+
+// Quota limits how many characters a backend translates per UTC day. Usage is
+// kept in memory, so a restart starts the day's count again.
+type Quota struct {
+	limit int
+	used  int
+	day   string
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// NewQuota creates a quota of limit characters per day; 0 means no limit
+func NewQuota(limit int) *Quota {
+	return &Quota{limit: limit, now: time.Now}
+}
+
+// Take reserves n characters of today's quota, reporting false without
+// reserving anything if that would go over the limit
+func (q *Quota) Take(n int) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.resetIfNewDay()
+	if q.limit > 0 && q.used+n > q.limit {
+		return false
+	}
+	q.used += n
+	return true
+}
+
+// Usage returns the characters used today and the daily limit
+func (q *Quota) Usage() (used, limit int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.resetIfNewDay()
+	return q.used, q.limit
+}
+
+// resetIfNewDay starts a new count when the UTC day changes
+func (q *Quota) resetIfNewDay() {
+	if day := q.now().UTC().Format("2006-01-02"); day != q.day {
+		q.day = day
+		q.used = 0
+	}
+}
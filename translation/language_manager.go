@@ -6,105 +6,167 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/you/moodbot/storage"
 )
 
 // UserLanguagePreference stores user language preferences
 type UserLanguagePreference struct {
-	UserID   int64    `json:"user_id"`
-	Language Language `json:"language"`
+	UserID    int64    `json:"user_id"`
+	Language  Language `json:"language"`
+	Tentative bool     `json:"tentative,omitempty"` // detected from Telegram, not chosen by the user
 }
 
 // LanguageManager manages user language preferences
 type LanguageManager struct {
 	preferences map[int64]Language
+	tentative   map[int64]bool
+	languages   *LanguageRegistry
 	mutex       sync.RWMutex
 	dataDir     string
 }
 
-// NewLanguageManager creates a new language manager
-func NewLanguageManager(dataDir string) *LanguageManager {
+// NewLanguageManager creates a new language manager offering the languages in registry
+func NewLanguageManager(dataDir string, registry *LanguageRegistry) *LanguageManager {
 	lm := &LanguageManager{
 		preferences: make(map[int64]Language),
+		tentative:   make(map[int64]bool),
+		languages:   registry,
 		dataDir:     dataDir,
 	}
-	
+
 	// Create data directory if it doesn't exist
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		fmt.Printf("Warning: Could not create language data directory: %v\n", err)
 	}
-	
+
 	// Load existing preferences
 	lm.loadPreferences()
-	
+
 	return lm
 }
 
-// SetUserLanguage sets the language preference for a user
+// SetUserLanguage sets the language preference for a user, replacing a detected one
 func (lm *LanguageManager) SetUserLanguage(userID int64, language Language) error {
 	lm.mutex.Lock()
 	defer lm.mutex.Unlock()
-	
+
 	lm.preferences[userID] = language
+	delete(lm.tentative, userID)
 	return lm.savePreferences()
 }
 
-// GetUserLanguage gets the language preference for a user (defaults to English)
+// DetectUserLanguage sets a tentative preference from the user's Telegram locale
+// if they have no preference yet and the locale maps to a configured language
+// other than English, the default. It reports the language and whether it was set.
+func (lm *LanguageManager) DetectUserLanguage(userID int64, locale string) (Language, bool) {
+	info, supported := lm.languages.Match(locale)
+	if !supported || info.Code == English {
+		return English, false
+	}
+
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	if _, exists := lm.preferences[userID]; exists {
+		return lm.preferences[userID], false
+	}
+	lm.preferences[userID] = info.Code
+	lm.tentative[userID] = true
+	if err := lm.savePreferences(); err != nil {
+		fmt.Printf("Error saving language preferences: %v\n", err)
+	}
+	return info.Code, true
+}
+
+// IsTentative reports whether the user's language was detected rather than chosen
+func (lm *LanguageManager) IsTentative(userID int64) bool {
+	lm.mutex.RLock()
+	defer lm.mutex.RUnlock()
+
+	return lm.tentative[userID]
+}
+
+// ConfirmUserLanguage makes a detected language the user's choice
+func (lm *LanguageManager) ConfirmUserLanguage(userID int64) error {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	if !lm.tentative[userID] {
+		return nil
+	}
+	delete(lm.tentative, userID)
+	return lm.savePreferences()
+}
+
+// GetUserLanguage gets the language preference for a user (defaults to English,
+// also when the chosen language is no longer configured)
 func (lm *LanguageManager) GetUserLanguage(userID int64) Language {
 	lm.mutex.RLock()
 	defer lm.mutex.RUnlock()
-	
+
 	if lang, exists := lm.preferences[userID]; exists {
-		return lang
+		if _, supported := lm.languages.Lookup(lang); supported {
+			return lang
+		}
 	}
 	return English // Default to English
 }
 
-// GetSupportedLanguages returns list of supported languages with their display names
-func (lm *LanguageManager) GetSupportedLanguages() map[Language]string {
-	return map[Language]string{
-		English: "English 🇺🇸",
-		Hindi:   "हिंदी 🇮🇳",
-		Tamil:   "தமிழ் 🇮🇳",
-	}
+// Languages returns the registry of languages users can choose
+func (lm *LanguageManager) Languages() *LanguageRegistry {
+	return lm.languages
+}
+
+// Close flushes language preferences to disk
+func (lm *LanguageManager) Close() error {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	return lm.savePreferences()
 }
 
 // loadPreferences loads user preferences from file
 func (lm *LanguageManager) loadPreferences() {
 	filePath := filepath.Join(lm.dataDir, "language_preferences.json")
-	
+
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		// File doesn't exist, that's okay
 		return
 	}
-	
+
 	var prefs []UserLanguagePreference
 	if err := json.Unmarshal(data, &prefs); err != nil {
 		fmt.Printf("Warning: Could not load language preferences: %v\n", err)
 		return
 	}
-	
+
 	for _, pref := range prefs {
 		lm.preferences[pref.UserID] = pref.Language
+		if pref.Tentative {
+			lm.tentative[pref.UserID] = true
+		}
 	}
 }
 
 // savePreferences saves user preferences to file
 func (lm *LanguageManager) savePreferences() error {
 	filePath := filepath.Join(lm.dataDir, "language_preferences.json")
-	
+
 	var prefs []UserLanguagePreference
 	for userID, lang := range lm.preferences {
 		prefs = append(prefs, UserLanguagePreference{
-			UserID:   userID,
-			Language: lang,
+			UserID:    userID,
+			Language:  lang,
+			Tentative: lm.tentative[userID],
 		})
 	}
-	
+
 	data, err := json.MarshalIndent(prefs, "", "  ")
 	if err != nil {
 		return err
 	}
-	
-	return os.WriteFile(filePath, data, 0644)
-}
\ No newline at end of file
+
+	return storage.WriteFileAtomic(filePath, data, 0644)
+}
@@ -0,0 +1,56 @@
+package translation
+
+import (
+	"testing"
+
+	"github.com/you/moodbot/config"
+)
+
+func TestDetectUserLanguage(t *testing.T) {
+	dataDir := t.TempDir()
+	registry := NewLanguageRegistry(config.Default().Languages)
+	lm := NewLanguageManager(dataDir, registry)
+
+	// English and unsupported locales keep the default without storing anything
+	for _, locale := range []string{"en", "pt-br", ""} {
+		if _, detected := lm.DetectUserLanguage(1, locale); detected {
+			t.Errorf("locale %q was detected", locale)
+		}
+	}
+
+	lang, detected := lm.DetectUserLanguage(1, "hi-IN")
+	if !detected || lang != "hi" || lm.GetUserLanguage(1) != "hi" || !lm.IsTentative(1) {
+		t.Fatalf("detected %q, %v; tentative %v", lang, detected, lm.IsTentative(1))
+	}
+
+	// Only the first interaction is detected
+	if _, detected := lm.DetectUserLanguage(1, "ta"); detected || lm.GetUserLanguage(1) != "hi" {
+		t.Errorf("second detection changed the language to %q", lm.GetUserLanguage(1))
+	}
+
+	// The tentative flag survives a restart
+	lm = NewLanguageManager(dataDir, registry)
+	if !lm.IsTentative(1) {
+		t.Error("tentative flag was not saved")
+	}
+
+	// An explicit choice overrides the detected language for good
+	if err := lm.SetUserLanguage(1, English); err != nil {
+		t.Fatal(err)
+	}
+	if lm.IsTentative(1) || lm.GetUserLanguage(1) != English {
+		t.Errorf("language = %q, tentative %v", lm.GetUserLanguage(1), lm.IsTentative(1))
+	}
+	if _, detected := lm.DetectUserLanguage(1, "hi"); detected {
+		t.Error("detection overrode an explicit choice")
+	}
+
+	// Keeping a detected language confirms it
+	lm.DetectUserLanguage(2, "ta")
+	if err := lm.ConfirmUserLanguage(2); err != nil {
+		t.Fatal(err)
+	}
+	if lm.IsTentative(2) || lm.GetUserLanguage(2) != "ta" {
+		t.Errorf("language = %q, tentative %v", lm.GetUserLanguage(2), lm.IsTentative(2))
+	}
+}
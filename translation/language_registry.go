@@ -0,0 +1,90 @@
+package translation
+
+import (
+	"strings"
+
+	"github.com/you/moodbot/config"
+)
+
+// rtlMark starts right-to-left text so Telegram lays it out correctly
+const rtlMark = "\u200f"
+
+// LanguageInfo describes a language users can choose
+type LanguageInfo struct {
+	Code      Language
+	Name      string // native name
+	Flag      string
+	Direction string // ltr or rtl
+}
+
+// Label returns the language's name and flag, as shown on its button
+func (l LanguageInfo) Label() string {
+	if l.Flag == "" {
+		return l.Name
+	}
+	return l.Name + " " + l.Flag
+}
+
+// RTL reports whether the language is written right to left
+func (l LanguageInfo) RTL() bool {
+	return l.Direction == "rtl"
+}
+
+// LanguageRegistry holds the configured languages, in the order they are offered
+type LanguageRegistry struct {
+	languages []LanguageInfo
+	byCode    map[Language]LanguageInfo
+}
+
+// NewLanguageRegistry creates a registry of the configured languages
+func NewLanguageRegistry(languages []config.LanguageConfig) *LanguageRegistry {
+	r := &LanguageRegistry{byCode: make(map[Language]LanguageInfo)}
+	for _, lang := range languages {
+		info := LanguageInfo{
+			Code:      Language(lang.Code),
+			Name:      lang.Name,
+			Flag:      lang.Flag,
+			Direction: lang.Direction,
+		}
+		r.languages = append(r.languages, info)
+		r.byCode[info.Code] = info
+	}
+	return r
+}
+
+// Languages returns every language, in configured order
+func (r *LanguageRegistry) Languages() []LanguageInfo {
+	return r.languages
+}
+
+// Lookup returns the language with this code
+func (r *LanguageRegistry) Lookup(code Language) (LanguageInfo, bool) {
+	info, exists := r.byCode[code]
+	return info, exists
+}
+
+// Match returns the language for a Telegram locale such as "hi" or "pt-br",
+// ignoring the region
+func (r *LanguageRegistry) Match(locale string) (LanguageInfo, bool) {
+	code := strings.ToLower(locale)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	return r.Lookup(Language(code))
+}
+
+// FormatText marks text as being in lang: its flag in front, and a
+// right-to-left mark for RTL languages. English text is left as is.
+func (r *LanguageRegistry) FormatText(text string, lang Language) string {
+	info, exists := r.byCode[lang]
+	if !exists || lang == English {
+		return text
+	}
+	if info.Flag != "" {
+		text = info.Flag + " " + text
+	}
+	if info.RTL() {
+		text = rtlMark + text
+	}
+	return text
+}
@@ -0,0 +1,59 @@
+package translation
+
+import (
+	"testing"
+
+	"github.com/you/moodbot/config"
+)
+
+func TestLanguageRegistry(t *testing.T) {
+	registry := NewLanguageRegistry([]config.LanguageConfig{
+		{Code: "en", Name: "English", Flag: "🇺🇸", Direction: "ltr"},
+		{Code: "bn", Name: "বাংলা", Flag: "🇧🇩", Direction: "ltr"},
+		{Code: "ur", Name: "اردو", Direction: "rtl"},
+	})
+
+	if langs := registry.Languages(); len(langs) != 3 || langs[1].Code != "bn" || langs[1].Label() != "বাংলা 🇧🇩" {
+		t.Errorf("languages = %+v", langs)
+	}
+	if _, exists := registry.Lookup("ta"); exists {
+		t.Error("ta is not configured")
+	}
+
+	tests := []struct {
+		lang Language
+		want string
+	}{
+		{English, "Hello"},
+		{"bn", "🇧🇩 Hello"},
+		{"ur", "\u200fHello"},
+		{"ta", "Hello"},
+	}
+	for _, tt := range tests {
+		if got := registry.FormatText("Hello", tt.lang); got != tt.want {
+			t.Errorf("FormatText(%s) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageRegistryMatch(t *testing.T) {
+	registry := NewLanguageRegistry(config.Default().Languages)
+
+	tests := []struct {
+		locale string
+		want   Language
+		ok     bool
+	}{
+		{"hi", "hi", true},
+		{"ta-IN", "ta", true},
+		{"en_US", English, true},
+		{"pt-br", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		info, ok := registry.Match(tt.locale)
+		if ok != tt.ok || info.Code != tt.want {
+			t.Errorf("Match(%q) = %q, %v, want %q, %v", tt.locale, info.Code, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package translation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/you/moodbot/config"
+)
+
+// libreTranslateRequest is the body of a LibreTranslate /translate request
+type libreTranslateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// LibreTranslateResponse represents a LibreTranslate /translate response
+type LibreTranslateResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+// LibreTranslateBackend translates with a LibreTranslate-compatible server,
+// such as a self-hosted one that keeps user content in-house
+type LibreTranslateBackend struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewLibreTranslateBackend creates a LibreTranslate backend
+func NewLibreTranslateBackend(cfg config.TranslationAPIConfig) *LibreTranslateBackend {
+	return &LibreTranslateBackend{
+		client:  &http.Client{Timeout: cfg.Timeout.Duration},
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
+	}
+}
+
+// Name returns the name the backend is configured under
+func (b *LibreTranslateBackend) Name() string { return LibreTranslateName }
+
+// Translate calls the server's /translate endpoint
+func (b *LibreTranslateBackend) Translate(ctx context.Context, text string, source, target Language) (string, error) {
+	body, err := json.Marshal(libreTranslateRequest{
+		Q:      text,
+		Source: string(source),
+		Target: string(target),
+		Format: "text",
+		APIKey: b.apiKey,
+	})
+	if err != nil {
+		return text, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", b.baseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return text, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return text, err
+	}
+	defer resp.Body.Close()
+
+	var translationResp LibreTranslateResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&translationResp)
+	if resp.StatusCode != http.StatusOK {
+		if translationResp.Error != "" {
+			return text, fmt.Errorf("translation API error: %d: %s", resp.StatusCode, translationResp.Error)
+		}
+		return text, fmt.Errorf("translation API error: %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return text, decodeErr
+	}
+	if translationResp.TranslatedText == "" {
+		return text, fmt.Errorf("empty translation returned")
+	}
+
+	return translationResp.TranslatedText, nil
+}
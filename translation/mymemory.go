@@ -0,0 +1,88 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/you/moodbot/config"
+)
+
+// MyMemoryResponse represents MyMemory Translation API response
+type MyMemoryResponse struct {
+	ResponseData struct {
+		TranslatedText string `json:"translatedText"`
+	} `json:"responseData"`
+	ResponseStatus int `json:"responseStatus"`
+}
+
+// MyMemoryBackend translates with the public MyMemory API (free, no API key required)
+type MyMemoryBackend struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewMyMemoryBackend creates a MyMemory backend
+func NewMyMemoryBackend(cfg config.TranslationAPIConfig) *MyMemoryBackend {
+	return &MyMemoryBackend{
+		client:  &http.Client{Timeout: cfg.Timeout.Duration},
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
+	}
+}
+
+// Name returns the name the backend is configured under
+func (b *MyMemoryBackend) Name() string { return MyMemoryName }
+
+// Translate calls the MyMemory API
+func (b *MyMemoryBackend) Translate(ctx context.Context, text string, source, target Language) (string, error) {
+	// Build API URL with parameters
+	apiURL := fmt.Sprintf("%s/get?q=%s&langpair=%s|%s",
+		b.baseURL, url.QueryEscape(text), string(source), string(target))
+	if b.apiKey != "" {
+		apiURL += "&key=" + url.QueryEscape(b.apiKey)
+	}
+
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return text, err // Fallback to original text
+	}
+
+	// Make request
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// The URL carries the API key and the text; keep them out of logs and /status
+		if urlErr, ok := err.(*url.Error); ok {
+			urlErr.URL = b.baseURL + "/get"
+		}
+		return text, err // Fallback to original text
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return text, fmt.Errorf("translation API error: %d", resp.StatusCode)
+	}
+
+	// Parse response
+	var translationResp MyMemoryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&translationResp); err != nil {
+		return text, err // Fallback to original text
+	}
+
+	// Check if translation was successful (status 200 = OK)
+	if translationResp.ResponseStatus != 200 {
+		return text, fmt.Errorf("translation failed with status: %d", translationResp.ResponseStatus)
+	}
+
+	translatedText := translationResp.ResponseData.TranslatedText
+	if translatedText == "" {
+		return text, fmt.Errorf("empty translation returned")
+	}
+
+	return translatedText, nil
+}
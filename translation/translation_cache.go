@@ -0,0 +1,196 @@
+package translation
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/you/moodbot/storage"
+)
+
+// saveEvery is how many new translations are cached between writes to disk
+const saveEvery = 20
+
+// cacheKey identifies a translation by its source text and language and its target language
+type cacheKey struct {
+	text   string
+	source Language
+	lang   Language
+}
+
+// MemoryEntry is one translation in the on-disk translation memory
+type MemoryEntry struct {
+	Text        string   `json:"text"`
+	Source      Language `json:"source,omitempty"` // empty in memories saved before sources were recorded, meaning English
+	Language    Language `json:"language"`
+	Translation string   `json:"translation"`
+}
+
+// key returns the cache key of the entry
+func (e MemoryEntry) key() cacheKey {
+	source := e.Source
+	if source == "" {
+		source = English
+	}
+	return cacheKey{e.Text, source, e.Language}
+}
+
+// CacheStats counts cache lookups
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+// TranslationCache is an LRU cache of translations, persisted as a translation
+// memory in the data directory so translations survive restarts
+type TranslationCache struct {
+	size    int
+	entries map[cacheKey]*list.Element // values are MemoryEntry
+	order   *list.List                 // most recently used first
+	hits    int64
+	misses  int64
+	unsaved int
+	mutex   sync.Mutex
+	dataDir string
+}
+
+// NewTranslationCache creates a cache holding up to size translations, loading
+// the translation memory saved in dataDir
+func NewTranslationCache(dataDir string, size int) *TranslationCache {
+	tc := &TranslationCache{
+		size:    size,
+		entries: make(map[cacheKey]*list.Element),
+		order:   list.New(),
+		dataDir: dataDir,
+	}
+
+	// Create data directory if it doesn't exist
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		fmt.Printf("Warning: Could not create translation data directory: %v\n", err)
+	}
+
+	tc.loadMemory()
+
+	return tc
+}
+
+// Get returns the cached translation of text from source into lang
+func (tc *TranslationCache) Get(text string, source, lang Language) (string, bool) {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	element, exists := tc.entries[cacheKey{text, source, lang}]
+	if !exists {
+		tc.misses++
+		return "", false
+	}
+	tc.hits++
+	tc.order.MoveToFront(element)
+	return element.Value.(MemoryEntry).Translation, true
+}
+
+// Contains reports whether a translation is cached, without counting as a lookup
+func (tc *TranslationCache) Contains(text string, source, lang Language) bool {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	_, exists := tc.entries[cacheKey{text, source, lang}]
+	return exists
+}
+
+// Put caches the translation of text from source into lang, evicting the least recently used translation when full
+func (tc *TranslationCache) Put(text string, source, lang Language, translation string) {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	tc.add(MemoryEntry{Text: text, Source: source, Language: lang, Translation: translation})
+
+	tc.unsaved++
+	if tc.unsaved >= saveEvery {
+		if err := tc.saveMemory(); err != nil {
+			fmt.Printf("Error saving translation memory: %v\n", err)
+		}
+	}
+}
+
+// Stats returns the number of hits, misses and cached translations
+func (tc *TranslationCache) Stats() CacheStats {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	return CacheStats{Hits: tc.hits, Misses: tc.misses, Entries: tc.order.Len()}
+}
+
+// Close flushes the translation memory to disk
+func (tc *TranslationCache) Close() error {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	return tc.saveMemory()
+}
+
+// add inserts or refreshes an entry as the most recently used
+func (tc *TranslationCache) add(entry MemoryEntry) {
+	key := entry.key()
+	if element, exists := tc.entries[key]; exists {
+		element.Value = entry
+		tc.order.MoveToFront(element)
+		return
+	}
+
+	tc.entries[key] = tc.order.PushFront(entry)
+	for tc.order.Len() > tc.size {
+		oldest := tc.order.Back()
+		evicted := oldest.Value.(MemoryEntry)
+		delete(tc.entries, evicted.key())
+		tc.order.Remove(oldest)
+	}
+}
+
+// getFilePath returns the file path for the translation memory
+func (tc *TranslationCache) getFilePath() string {
+	return filepath.Join(tc.dataDir, "translations.json")
+}
+
+// loadMemory loads the translation memory from file
+func (tc *TranslationCache) loadMemory() {
+	data, err := os.ReadFile(tc.getFilePath())
+	if err != nil {
+		// File doesn't exist, that's okay
+		return
+	}
+
+	var memory []MemoryEntry
+	if err := json.Unmarshal(data, &memory); err != nil {
+		fmt.Printf("Warning: Could not load translation memory: %v\n", err)
+		return
+	}
+
+	// The file is most recently used first; add oldest first so the order is kept
+	for i := len(memory) - 1; i >= 0; i-- {
+		tc.add(memory[i])
+	}
+}
+
+// saveMemory saves the translation memory to file, most recently used first
+func (tc *TranslationCache) saveMemory() error {
+	memory := make([]MemoryEntry, 0, tc.order.Len())
+	for element := tc.order.Front(); element != nil; element = element.Next() {
+		memory = append(memory, element.Value.(MemoryEntry))
+	}
+
+	data, err := json.MarshalIndent(memory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling translation memory: %v", err)
+	}
+
+	if err := storage.WriteFileAtomic(tc.getFilePath(), data, 0644); err != nil {
+		return err
+	}
+	tc.unsaved = 0
+	return nil
+}
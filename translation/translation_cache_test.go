@@ -0,0 +1,118 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/you/moodbot/config"
+)
+
+func TestTranslationCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewTranslationCache(t.TempDir(), 2)
+	cache.Put("Funny", English, hindi, "मज़ेदार")
+	cache.Put("Calm", English, hindi, "शांत")
+	cache.Get("Funny", English, hindi) // Calm is now the least recently used
+	cache.Put("Inspiring", English, hindi, "प्रेरक")
+
+	if _, ok := cache.Get("Calm", English, hindi); ok {
+		t.Error("Calm should have been evicted")
+	}
+	if got, ok := cache.Get("Funny", English, hindi); !ok || got != "मज़ेदार" {
+		t.Errorf("Funny = %q, %v", got, ok)
+	}
+	if _, ok := cache.Get("Funny", English, tamil); ok {
+		t.Error("translations are cached per language")
+	}
+
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestTranslationCachePersists(t *testing.T) {
+	dataDir := t.TempDir()
+	cache := NewTranslationCache(dataDir, 10)
+	cache.Put("Funny", English, hindi, "मज़ेदार")
+	cache.Put("Calm", English, tamil, "அமைதி")
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Reloading into a smaller cache keeps the most recently used translation
+	reloaded := NewTranslationCache(dataDir, 1)
+	if got, ok := reloaded.Get("Calm", English, tamil); !ok || got != "அமைதி" {
+		t.Errorf("Calm = %q, %v", got, ok)
+	}
+	if _, ok := reloaded.Get("Funny", English, hindi); ok {
+		t.Error("Funny should not fit in the reloaded cache")
+	}
+}
+
+func TestTranslatorCachesTranslations(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var resp MyMemoryResponse
+		resp.ResponseStatus = 200
+		resp.ResponseData.TranslatedText = "[" + r.URL.Query().Get("langpair") + "] " + r.URL.Query().Get("q")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	cfg := config.Default().Translation
+	cfg.MyMemory.BaseURL = server.URL
+	translator := NewTranslator(cfg, NewTranslationCache(t.TempDir(), cfg.CacheSize))
+
+	for i := 0; i < 3; i++ {
+		got, err := translator.TranslateText(context.Background(), "Stay hungry, stay foolish.", hindi)
+		if err != nil || got != "[en|hi] Stay hungry, stay foolish." {
+			t.Errorf("TranslateText = %q, %v", got, err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("translation API called %d times, want 1", n)
+	}
+	if stats := translator.CacheStats(); stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestTranslatorWarmup(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var resp MyMemoryResponse
+		resp.ResponseStatus = 200
+		resp.ResponseData.TranslatedText = "[" + r.URL.Query().Get("langpair") + "] " + r.URL.Query().Get("q")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	cfg := config.Default().Translation
+	cfg.MyMemory.BaseURL = server.URL
+	translator := NewTranslator(cfg, NewTranslationCache(t.TempDir(), cfg.CacheSize))
+
+	texts := []string{"Cozy", "Nostalgic"}
+	langs := []Language{English, hindi, tamil}
+	if added := translator.Warmup(context.Background(), texts, langs); added != 4 {
+		t.Errorf("Warmup added %d translations, want 4", added)
+	}
+	if added := translator.Warmup(context.Background(), texts, langs); added != 0 {
+		t.Errorf("second Warmup added %d translations, want 0", added)
+	}
+
+	got, err := translator.TranslateText(context.Background(), "Cozy", hindi)
+	if err != nil || got != "[en|hi] Cozy" {
+		t.Errorf("TranslateText = %q, %v", got, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 4 {
+		t.Errorf("translation API called %d times, want 4", n)
+	}
+	if stats := translator.CacheStats(); stats.Hits != 1 || stats.Entries != 4 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -2,125 +2,192 @@ package translation
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/health"
 )
 
-// Language represents supported languages
+// Language is a language code such as "hi"; the languages users can choose are in the LanguageRegistry
 type Language string
 
-const (
-	English Language = "en"
-	Hindi   Language = "hi"
-	Tamil   Language = "ta"
-)
+// English is the language content is assumed to be written in when it doesn't say, and the default
+const English Language = "en"
 
-// MyMemoryResponse represents MyMemory Translation API response
-type MyMemoryResponse struct {
-	ResponseData struct {
-		TranslatedText string `json:"translatedText"`
-	} `json:"responseData"`
-	ResponseStatus int `json:"responseStatus"`
+// maxTextLength limits how much of a text is sent for translation
+const maxTextLength = 500
+
+// backend is a configured translation backend with its quota and health
+type backend struct {
+	TranslationBackend
+	quota  *Quota
+	health *health.Tracker
 }
 
-// Translator handles translation operations
-type Translator struct {
-	client *http.Client
+// BackendStatus describes a translation backend for /status
+type BackendStatus struct {
+	Name       string
+	Health     health.Snapshot
+	QuotaUsed  int // characters today
+	QuotaLimit int // 0 means no limit
 }
 
-// NewTranslator creates a new translator instance
-func NewTranslator() *Translator {
-	return &Translator{
-		client: &http.Client{Timeout: 10 * time.Second},
-	}
+// Translator handles translation operations, trying its backends in order
+type Translator struct {
+	backends []*backend
+	cache    *TranslationCache
 }
 
-// TranslateText translates text to the specified language using MyMemory API
-func (t *Translator) TranslateText(ctx context.Context, text string, targetLang Language) (string, error) {
-	// If target language is English, return original text
-	if targetLang == English {
-		return text, nil
-	}
+// NewTranslator creates a translator using the configured backend and then its
+// fallbacks; cache may be nil to always call the backends
+func NewTranslator(cfg config.TranslationConfig, cache *TranslationCache) *Translator {
+	t := &Translator{cache: cache}
 
-	// MyMemory Translation API endpoint (free, no API key required)
-	// Limit text length to avoid issues with very long content
-	if len(text) > 500 {
-		text = text[:500] + "..."
+	quotas := map[string]int{
+		MyMemoryName:       cfg.MyMemory.DailyQuota,
+		LibreTranslateName: cfg.LibreTranslate.DailyQuota,
+	}
+	for _, name := range append([]string{cfg.Backend}, cfg.Fallbacks...) {
+		b, err := NewBackend(name, cfg)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		t.AddBackend(b, quotas[name])
 	}
+	return t
+}
 
-	// Build API URL with parameters
-	apiURL := fmt.Sprintf("https://api.mymemory.translated.net/get?q=%s&langpair=%s|%s",
-		url.QueryEscape(text), "en", string(targetLang))
+// AddBackend adds a backend to the end of the chain, limited to dailyQuota characters a day (0 for no limit)
+func (t *Translator) AddBackend(b TranslationBackend, dailyQuota int) {
+	t.backends = append(t.backends, &backend{
+		TranslationBackend: b,
+		quota:              NewQuota(dailyQuota),
+		health:             health.NewTracker(),
+	})
+}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return text, err // Fallback to original text
+// CacheStats returns the translation cache's hits, misses and size
+func (t *Translator) CacheStats() CacheStats {
+	if t.cache == nil {
+		return CacheStats{}
 	}
+	return t.cache.Stats()
+}
 
-	// Make request
-	resp, err := t.client.Do(req)
-	if err != nil {
-		return text, err // Fallback to original text
+// Warmup translates English texts into each of langs ahead of time so the first
+// users don't wait for them. It returns how many translations were added to the cache.
+func (t *Translator) Warmup(ctx context.Context, texts []string, langs []Language) int {
+	if t.cache == nil {
+		return 0
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return text, fmt.Errorf("translation API error: %d", resp.StatusCode)
+	added := 0
+	for _, lang := range langs {
+		for _, text := range texts {
+			if lang == English || t.cache.Contains(text, English, lang) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return added
+			}
+			if _, err := t.TranslateText(ctx, text, lang); err == nil {
+				added++
+			}
+		}
 	}
+	return added
+}
 
-	// Parse response
-	var translationResp MyMemoryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&translationResp); err != nil {
-		return text, err // Fallback to original text
+// Backends returns the health and quota usage of each backend, in the order they are tried
+func (t *Translator) Backends() []BackendStatus {
+	statuses := make([]BackendStatus, 0, len(t.backends))
+	for _, b := range t.backends {
+		used, limit := b.quota.Usage()
+		statuses = append(statuses, BackendStatus{
+			Name:       b.Name(),
+			Health:     b.health.Snapshot(),
+			QuotaUsed:  used,
+			QuotaLimit: limit,
+		})
 	}
+	return statuses
+}
 
-	// Check if translation was successful (status 200 = OK)
-	if translationResp.ResponseStatus != 200 {
-		return text, fmt.Errorf("translation failed with status: %d", translationResp.ResponseStatus)
+// TranslateText translates English text to the specified language, like TranslateFrom
+func (t *Translator) TranslateText(ctx context.Context, text string, targetLang Language) (string, error) {
+	return t.TranslateFrom(ctx, text, English, targetLang)
+}
+
+// TranslateFrom translates text written in sourceLang (English if empty) to targetLang,
+// serving repeated translations from the cache. Each backend is tried in turn, skipping
+// those over their daily quota; if none succeeds, for instance because none handles the
+// language pair, the original text is returned with the error.
+func (t *Translator) TranslateFrom(ctx context.Context, text string, sourceLang, targetLang Language) (string, error) {
+	if sourceLang == "" {
+		sourceLang = English
+	}
+	if sourceLang == targetLang {
+		return text, nil
 	}
 
-	translatedText := translationResp.ResponseData.TranslatedText
-	if translatedText == "" {
-		return text, fmt.Errorf("empty translation returned")
+	if t.cache != nil {
+		if translated, ok := t.cache.Get(text, sourceLang, targetLang); ok {
+			return translated, nil
+		}
 	}
 
-	return translatedText, nil
-}
+	// Limit text length to avoid issues with very long content
+	query := text
+	if len(query) > maxTextLength {
+		query = query[:maxTextLength] + "..."
+	}
 
-// GetLanguageFromCommand determines language from user command or preference
-func GetLanguageFromCommand(command string) Language {
-	command = strings.ToLower(command)
-	
-	switch {
-	case strings.Contains(command, "tamil") || strings.Contains(command, "ta"):
-		return Tamil
-	case strings.Contains(command, "hindi") || strings.Contains(command, "hi"):
-		return Hindi
-	default:
-		return English
+	var errs []string
+	for _, b := range t.backends {
+		if !b.quota.Take(utf8.RuneCountInString(query)) {
+			errs = append(errs, fmt.Sprintf("%s: %v", b.Name(), ErrQuotaExceeded))
+			continue
+		}
+
+		start := time.Now()
+		translated, err := b.Translate(ctx, query, sourceLang, targetLang)
+		if !errors.Is(err, context.Canceled) {
+			b.health.Record(time.Since(start), err)
+		}
+		if err == nil {
+			// Untranslated text must not be remembered as a translation
+			if _, noop := b.TranslationBackend.(NoopBackend); !noop && t.cache != nil {
+				t.cache.Put(text, sourceLang, targetLang, translated)
+			}
+			return translated, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", b.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
 	}
-}
 
-// FormatLanguageSpecificText formats text based on language requirements
-func FormatLanguageSpecificText(text string, lang Language) string {
-	switch lang {
-	case Tamil:
-		// Add Tamil-specific formatting if needed
-		return "🇮🇳 " + text
-	case Hindi:
-		// Add Hindi-specific formatting if needed
-		return "🇮🇳 " + text
-	default:
-		return text
+	if len(errs) == 0 {
+		return text, fmt.Errorf("no translation backend configured")
 	}
+	return text, fmt.Errorf("translation failed: %s", strings.Join(errs, "; "))
 }
 
-// IsTranslationSupported checks if translation is available
+// IsTranslationSupported reports whether any backend can translate right now:
+// one that isn't the no-op backend and has quota left today
 func (t *Translator) IsTranslationSupported() bool {
-	return true // MyMemory API is always available (no API key needed)
-}
\ No newline at end of file
+	for _, b := range t.backends {
+		if _, noop := b.TranslationBackend.(NoopBackend); noop {
+			continue
+		}
+		if used, limit := b.quota.Usage(); limit == 0 || used < limit {
+			return true
+		}
+	}
+	return false
+}
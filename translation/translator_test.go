@@ -0,0 +1,155 @@
+package translation
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/you/moodbot/config"
+	"github.com/you/moodbot/fakeapis"
+)
+
+// Languages used in the tests
+const (
+	hindi Language = "hi"
+	tamil Language = "ta"
+)
+
+// newTestTranslator builds a translator for the given backend chain, pointed at fake APIs
+func newTestTranslator(t *testing.T, backends ...string) (*Translator, *fakeapis.APIs) {
+	t.Helper()
+
+	apis := fakeapis.Start()
+	t.Cleanup(apis.Close)
+	cfg := config.Default()
+	apis.Configure(&cfg)
+	cfg.Translation.Backend = backends[0]
+	cfg.Translation.Fallbacks = backends[1:]
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return NewTranslator(cfg.Translation, NewTranslationCache(t.TempDir(), cfg.Translation.CacheSize)), apis
+}
+
+func TestTranslatorBackends(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    string
+	}{
+		{MyMemoryName, "[hi] Keep going."},
+		{LibreTranslateName, "[hi] Keep going."},
+		{NoopName, "Keep going."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			translator, _ := newTestTranslator(t, tt.backend)
+			got, err := translator.TranslateText(context.Background(), "Keep going.", hindi)
+			if err != nil || got != tt.want {
+				t.Errorf("TranslateText = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslatorFallsBack(t *testing.T) {
+	translator, apis := newTestTranslator(t, LibreTranslateName, MyMemoryName)
+	apis.LibreTranslate.SetMode(fakeapis.Error)
+
+	got, err := translator.TranslateText(context.Background(), "Keep going.", tamil)
+	if err != nil || got != "[ta] Keep going." {
+		t.Errorf("TranslateText = %q, %v", got, err)
+	}
+	if apis.LibreTranslate.Requests() != 1 || apis.MyMemory.Requests() != 1 {
+		t.Errorf("requests = %d libretranslate, %d mymemory, want 1 each", apis.LibreTranslate.Requests(), apis.MyMemory.Requests())
+	}
+
+	statuses := translator.Backends()
+	if len(statuses) != 2 || statuses[0].Health.Failures != 1 || statuses[1].Health.Successes != 1 {
+		t.Errorf("backends = %+v", statuses)
+	}
+}
+
+func TestTranslatorSourceLanguage(t *testing.T) {
+	for _, backend := range []string{MyMemoryName, LibreTranslateName} {
+		t.Run(backend, func(t *testing.T) {
+			translator, apis := newTestTranslator(t, backend)
+
+			got, err := translator.TranslateFrom(context.Background(), "मन के हारे हार है।", hindi, tamil)
+			if err != nil || got != "[hi|ta] मन के हारे हार है।" {
+				t.Errorf("TranslateFrom = %q, %v, want it translated from Hindi", got, err)
+			}
+			if got, _ := translator.TranslateFrom(context.Background(), "मन के हारे हार है।", hindi, hindi); got != "मन के हारे हार है।" {
+				t.Errorf("TranslateFrom into the source language = %q, want the original", got)
+			}
+			if n := apis.MyMemory.Requests() + apis.LibreTranslate.Requests(); n != 1 {
+				t.Errorf("backends got %d requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestTranslatorNoopIsNotCached(t *testing.T) {
+	translator, apis := newTestTranslator(t, MyMemoryName, NoopName)
+	apis.MyMemory.SetMode(fakeapis.Error)
+
+	got, err := translator.TranslateText(context.Background(), "Keep going.", hindi)
+	if err != nil || got != "Keep going." {
+		t.Errorf("TranslateText = %q, %v, want the original text", got, err)
+	}
+	if stats := translator.CacheStats(); stats.Entries != 0 {
+		t.Errorf("cached %d untranslated texts", stats.Entries)
+	}
+
+	// Once MyMemory is back the text is translated
+	apis.MyMemory.SetMode(fakeapis.OK)
+	if got, _ := translator.TranslateText(context.Background(), "Keep going.", hindi); got != "[hi] Keep going." {
+		t.Errorf("TranslateText = %q", got)
+	}
+}
+
+func TestTranslatorQuota(t *testing.T) {
+	translator := &Translator{}
+	translator.AddBackend(NoopBackend{}, 0)
+	if translator.IsTranslationSupported() {
+		t.Error("the no-op backend can't translate")
+	}
+
+	translator, apis := newTestTranslator(t, MyMemoryName)
+	translator.backends[0].quota = NewQuota(len("Keep going."))
+	if !translator.IsTranslationSupported() {
+		t.Error("translation should be supported within quota")
+	}
+
+	if _, err := translator.TranslateText(context.Background(), "Keep going.", hindi); err != nil {
+		t.Fatal(err)
+	}
+	got, err := translator.TranslateText(context.Background(), "One more.", hindi)
+	if err == nil || !strings.Contains(err.Error(), "mymemory: daily quota exceeded") || got != "One more." {
+		t.Errorf("TranslateText over quota = %q, %v", got, err)
+	}
+	if apis.MyMemory.Requests() != 1 {
+		t.Errorf("MyMemory got %d requests, want 1", apis.MyMemory.Requests())
+	}
+	if translator.IsTranslationSupported() {
+		t.Error("translation should not be supported once the quota is used up")
+	}
+}
+
+func TestQuotaResetsDaily(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	q := NewQuota(10)
+	q.now = func() time.Time { return now }
+
+	if !q.Take(8) || q.Take(3) {
+		t.Fatal("quota of 10 should allow 8 characters but not 3 more")
+	}
+	now = now.Add(2 * time.Hour)
+	if !q.Take(10) {
+		t.Error("quota did not reset on a new day")
+	}
+	if used, limit := q.Usage(); used != 10 || limit != 10 {
+		t.Errorf("usage = %d/%d", used, limit)
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/you/moodbot/models"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/moodbot/models"
 )
 
 // VoteManager handles all voting-related functionality
@@ -23,38 +23,37 @@ func NewVoteManager() *VoteManager {
 	}
 }
 
-// CreateVotingKeyboard creates inline keyboard with voting buttons
-func (vm *VoteManager) CreateVotingKeyboard(contentType string, messageID int) tgbotapi.InlineKeyboardMarkup {
+// CreateVotingKeyboard creates inline keyboard with voting buttons for a delivered item
+func (vm *VoteManager) CreateVotingKeyboard(contentType string, deliveryID string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("👍", fmt.Sprintf("vote_%s_%d_up", contentType, messageID)),
-			tgbotapi.NewInlineKeyboardButtonData("👎", fmt.Sprintf("vote_%s_%d_down", contentType, messageID)),
-			tgbotapi.NewInlineKeyboardButtonData("⭐", "favorite_add"),
+			tgbotapi.NewInlineKeyboardButtonData("👍", fmt.Sprintf("vote_%s_%s_up", contentType, deliveryID)),
+			tgbotapi.NewInlineKeyboardButtonData("👎", fmt.Sprintf("vote_%s_%s_down", contentType, deliveryID)),
+			tgbotapi.NewInlineKeyboardButtonData("⭐", fmt.Sprintf("favorite_add_%s", deliveryID)),
 		),
 	)
 }
 
-// HandleVote processes a vote from callback data
-func (vm *VoteManager) HandleVote(data string, userID int64) string {
-	// Parse vote data: vote_{type}_{messageID}_{up/down}
+// HandleVote processes a vote from callback data and reports whether it was a thumbs up
+func (vm *VoteManager) HandleVote(data string, userID int64) (bool, error) {
+	// Parse vote data: vote_{type}_{deliveryID}_{up/down}
 	parts := strings.Split(data, "_")
 	if len(parts) < 4 {
-		return "Invalid vote data"
+		return false, fmt.Errorf("invalid vote data: %s", data)
 	}
 
 	contentType := parts[1]
-	messageID := 0
-	fmt.Sscanf(parts[2], "%d", &messageID)
+	deliveryID := parts[2]
 	voteType := parts[3]
 
 	vote := models.Vote{
-		MessageID: messageID,
-		UserID:    userID,
-		Vote:      voteType == "up",
+		DeliveryID: deliveryID,
+		UserID:     userID,
+		Vote:       voteType == "up",
 	}
 
 	vm.votesMutex.Lock()
-	key := fmt.Sprintf("%s_%d", contentType, messageID)
+	key := fmt.Sprintf("%s_%s", contentType, deliveryID)
 
 	// Remove existing vote from same user for this content
 	existingVotes := vm.votes[key]
@@ -70,18 +69,15 @@ func (vm *VoteManager) HandleVote(data string, userID int64) string {
 	vm.votes[key] = filteredVotes
 	vm.votesMutex.Unlock()
 
-	if voteType == "up" {
-		return "Thanks for the thumbs up! 👍"
-	}
-	return "Thanks for the feedback! 👎"
+	return vote.Vote, nil
 }
 
 // GetVoteStats returns voting statistics for analytics (future use)
-func (vm *VoteManager) GetVoteStats(contentType string, messageID int) (int, int) {
+func (vm *VoteManager) GetVoteStats(contentType string, deliveryID string) (int, int) {
 	vm.votesMutex.RLock()
 	defer vm.votesMutex.RUnlock()
 
-	key := fmt.Sprintf("%s_%d", contentType, messageID)
+	key := fmt.Sprintf("%s_%s", contentType, deliveryID)
 	votes := vm.votes[key]
 
 	upVotes, downVotes := 0, 0
@@ -99,4 +95,4 @@ func (vm *VoteManager) GetVoteStats(contentType string, messageID int) (int, int
 // IsVoteCallback checks if callback data is a vote
 func (vm *VoteManager) IsVoteCallback(data string) bool {
 	return len(data) > 5 && data[:5] == "vote_"
-}
\ No newline at end of file
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SecretTokenHeader is the header Telegram sends the webhook secret token in
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Server receives Telegram updates over HTTP and feeds them into an updates channel
+type Server struct {
+	path        string
+	secretToken string
+	updates     chan tgbotapi.Update
+	server      *http.Server
+	done        chan struct{} // closed when shutdown starts
+	closed      bool          // whether updates is closed
+	mutex       sync.RWMutex  // held for reading while sending on updates
+	closeOnce   sync.Once
+}
+
+// NewServer creates a webhook server listening on addr that accepts updates posted to path.
+// If secretToken is set, requests without a matching secret-token header are rejected.
+func NewServer(addr, path, secretToken string) *Server {
+	s := &Server{
+		path:        path,
+		secretToken: secretToken,
+		updates:     make(chan tgbotapi.Update, 100),
+		done:        make(chan struct{}),
+	}
+	s.server = &http.Server{Addr: addr, Handler: s}
+	return s
+}
+
+// Updates returns the channel updates are delivered on, like bot.GetUpdatesChan
+func (s *Server) Updates() tgbotapi.UpdatesChannel {
+	return s.updates
+}
+
+// ServeHTTP handles a single update posted by Telegram
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != s.path {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.secretToken != "" {
+		got := r.Header.Get(SecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.secretToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&update); err != nil {
+		http.Error(w, "invalid update", http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.closed {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Block until there's room so Telegram retries if we're overloaded
+	select {
+	case s.updates <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-r.Context().Done():
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	case <-s.done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+	}
+}
+
+// ListenAndServe starts the HTTP server; it returns http.ErrServerClosed after Shutdown
+func (s *Server) ListenAndServe() error {
+	return s.server.ListenAndServe()
+}
+
+// Shutdown stops accepting requests, waits for in-flight ones and closes the
+// updates channel. Requests still waiting for room in the channel are answered
+// 503 so Telegram delivers them again later.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.done) })
+	err := s.server.Shutdown(ctx)
+
+	// Even after a timeout no handler can be sending once the lock is held
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.updates)
+	}
+	return err
+}
+
+// Register points the bot's webhook at url with the given secret token
+func Register(bot *tgbotapi.BotAPI, url, secretToken string) error {
+	params := tgbotapi.Params{}
+	params["url"] = url
+	params.AddNonEmpty("secret_token", secretToken)
+	_, err := bot.MakeRequest("setWebhook", params)
+	return err
+}
@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSecret = "s3cret"
+
+// post sends body to the test server's webhook path with the given secret token, if any.
+// It may be called from other goroutines, so errors don't stop the test.
+func post(t *testing.T, url string, body []byte, secret string) int {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, url+"/webhook", bytes.NewReader(body))
+	if secret != "" {
+		req.Header.Set(SecretTokenHeader, secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServerDeliversUpdates(t *testing.T) {
+	s := NewServer("", "/webhook", testSecret)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	files, err := filepath.Glob("testdata/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no test updates: %v", err)
+	}
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := post(t, ts.URL, body, testSecret); status != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", file, status)
+		}
+		update := <-s.Updates()
+		if update.UpdateID == 0 || (update.Message == nil && update.CallbackQuery == nil) {
+			t.Errorf("%s: decoded update = %+v", file, update)
+		}
+	}
+}
+
+func TestServerRejectsRequests(t *testing.T) {
+	s := NewServer("", "/webhook", testSecret)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	body, err := os.ReadFile("testdata/start_command.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		body   []byte
+		secret string
+		want   int
+	}{
+		{"missing secret", body, "", http.StatusForbidden},
+		{"wrong secret", body, "wrong", http.StatusForbidden},
+		{"invalid update", []byte("{"), testSecret, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if status := post(t, ts.URL, tt.body, tt.secret); status != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.want)
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want 405", resp.StatusCode)
+	}
+	if len(s.Updates()) != 0 {
+		t.Error("a rejected request was delivered")
+	}
+}
+
+func TestShutdownWithBlockedRequest(t *testing.T) {
+	s := NewServer("", "/webhook", "")
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	body, err := os.ReadFile("testdata/start_command.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill the channel so the next request blocks waiting for room
+	for len(s.updates) < cap(s.updates) {
+		if status := post(t, ts.URL, body, ""); status != http.StatusOK {
+			t.Fatalf("status = %d, want 200", status)
+		}
+	}
+	blocked := make(chan int)
+	go func() { blocked <- post(t, ts.URL, body, "") }()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	s.Shutdown(ctx)
+
+	if status := <-blocked; status != http.StatusServiceUnavailable {
+		t.Errorf("blocked request status = %d, want 503", status)
+	}
+	if status := post(t, ts.URL, body, ""); status != http.StatusServiceUnavailable {
+		t.Errorf("status after shutdown = %d, want 503", status)
+	}
+
+	// The channel is drained and then closed
+	count := 0
+	for range s.Updates() {
+		count++
+	}
+	if count != cap(s.updates) {
+		t.Errorf("drained %d updates, want %d", count, cap(s.updates))
+	}
+}
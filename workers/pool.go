@@ -0,0 +1,150 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrClosed is returned when submitting to a closed pool
+var ErrClosed = errors.New("worker pool is closed")
+
+// Pool processes updates concurrently while keeping updates from the same chat in order.
+// Each chat has its own FIFO queue and at most one worker handles a chat at a time,
+// so a slow chat never blocks the others. The total number of queued updates is bounded;
+// Submit blocks when the pool is full.
+type Pool struct {
+	handle    func(tgbotapi.Update)
+	pending   map[int64][]tgbotapi.Update // chat ID -> queued updates
+	ready     chan int64                  // chats with pending updates and no active worker
+	queued    int
+	maxQueued int
+	closed    bool
+	mutex     sync.Mutex
+	notFull   *sync.Cond
+	inflight  sync.WaitGroup // updates submitted but not yet handled
+	workers   sync.WaitGroup
+}
+
+// NewPool starts a pool of workers that pass each update to handle
+func NewPool(workers, queueSize int, handle func(tgbotapi.Update)) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	p := &Pool{
+		handle:    handle,
+		pending:   make(map[int64][]tgbotapi.Update),
+		ready:     make(chan int64, queueSize),
+		maxQueued: queueSize,
+	}
+	p.notFull = sync.NewCond(&p.mutex)
+
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues an update, blocking while the pool is full
+func (p *Pool) Submit(update tgbotapi.Update) error {
+	chatID := ChatKey(update)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for p.queued >= p.maxQueued && !p.closed {
+		p.notFull.Wait()
+	}
+	if p.closed {
+		return ErrClosed
+	}
+
+	p.pending[chatID] = append(p.pending[chatID], update)
+	p.queued++
+	p.inflight.Add(1)
+
+	// A chat is scheduled when its queue goes from empty to non-empty;
+	// otherwise the worker handling it picks the update up
+	if len(p.pending[chatID]) == 1 {
+		p.ready <- chatID
+	}
+	return nil
+}
+
+// Close stops accepting updates, waits for queued ones to be handled and stops the workers
+func (p *Pool) Close() {
+	_ = p.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting updates and waits for queued ones to be handled.
+// It returns ctx.Err() if the deadline passes first; the remaining updates are still handled in the background.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mutex.Lock()
+	if !p.closed {
+		p.closed = true
+		p.notFull.Broadcast()
+		go func() {
+			p.inflight.Wait()
+			close(p.ready)
+		}()
+	}
+	p.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work handles one update at a time from whichever chat is ready
+func (p *Pool) work() {
+	defer p.workers.Done()
+
+	for chatID := range p.ready {
+		p.mutex.Lock()
+		update := p.pending[chatID][0]
+		p.mutex.Unlock()
+
+		p.handle(update)
+
+		p.mutex.Lock()
+		p.pending[chatID] = p.pending[chatID][1:]
+		if len(p.pending[chatID]) > 0 {
+			// Requeue the chat behind the others so a busy chat can't starve them
+			p.ready <- chatID
+		} else {
+			delete(p.pending, chatID)
+		}
+		p.queued--
+		p.notFull.Signal()
+		p.mutex.Unlock()
+
+		p.inflight.Done()
+	}
+}
+
+// ChatKey returns the chat an update belongs to, falling back to the sender
+func ChatKey(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func update(id int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{UpdateID: id, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func TestPoolKeepsChatOrder(t *testing.T) {
+	var mutex sync.Mutex
+	handled := make(map[int64][]int)
+	p := NewPool(4, 10, func(u tgbotapi.Update) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		chatID := u.Message.Chat.ID
+		handled[chatID] = append(handled[chatID], u.UpdateID)
+	})
+
+	var want []int
+	for i := 0; i < 50; i++ {
+		want = append(want, i)
+		for chatID := int64(1); chatID <= 3; chatID++ {
+			if err := p.Submit(update(i, chatID)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	p.Close()
+
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		if !reflect.DeepEqual(handled[chatID], want) {
+			t.Errorf("chat %d handled %v, want in order", chatID, handled[chatID])
+		}
+	}
+}
+
+func TestPoolRunsChatsInParallel(t *testing.T) {
+	otherDone := make(chan struct{})
+	p := NewPool(2, 10, func(u tgbotapi.Update) {
+		if u.Message.Chat.ID == 2 {
+			close(otherDone)
+			return
+		}
+		// Chat 1 only finishes once chat 2 was handled alongside it
+		select {
+		case <-otherDone:
+		case <-time.After(time.Second):
+			t.Error("a slow chat blocked another chat")
+		}
+	})
+
+	p.Submit(update(1, 1))
+	p.Submit(update(2, 2))
+	p.Close()
+}
+
+func TestPoolBlocksWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	p := NewPool(1, 2, func(u tgbotapi.Update) { <-release })
+
+	for i := 0; i < 2; i++ {
+		if err := p.Submit(update(i, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	submitted := make(chan error)
+	go func() { submitted <- p.Submit(update(2, 1)) }()
+	select {
+	case err := <-submitted:
+		t.Fatalf("Submit() = %v on a full pool, want it to block", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Handling one update makes room
+	release <- struct{}{}
+	if err := <-submitted; err != nil {
+		t.Fatalf("Submit() = %v after room was made", err)
+	}
+
+	// A Submit still waiting for room when the pool shuts down fails
+	go func() { submitted <- p.Submit(update(3, 1)) }()
+	time.Sleep(20 * time.Millisecond)
+	go p.Shutdown(context.Background())
+	if err := <-submitted; !errors.Is(err, ErrClosed) {
+		t.Errorf("blocked Submit() = %v, want ErrClosed", err)
+	}
+	close(release)
+}
+
+func TestSubmitAfterShutdown(t *testing.T) {
+	release := make(chan struct{})
+	handled := 0
+	p := NewPool(1, 10, func(u tgbotapi.Update) {
+		<-release
+		handled++
+	})
+	p.Submit(update(1, 1))
+
+	// Shutdown gives up at the deadline while the update is still being handled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want the deadline", err)
+	}
+	if err := p.Submit(update(2, 1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit() after Shutdown = %v, want ErrClosed", err)
+	}
+
+	// The queued update is still handled
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if handled != 1 {
+		t.Errorf("handled %d updates, want 1", handled)
+	}
+}